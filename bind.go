@@ -0,0 +1,288 @@
+package httprouter
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes a value that couldn't be bound to a struct field.
+type FieldError struct {
+	// The name of the struct field, dotted for nested structs (e.g. "Address.Zip").
+	Field string
+	// The source of the value: "body", "form", "query", "header" or "param".
+	Source string
+	// The name of the value in the source (e.g. the query parameter name).
+	Name string
+	// The error that occurred.
+	Err error
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s %q: %v", e.Field, e.Source, e.Name, e.Err)
+}
+
+// Unwrap returns the error that occurred.
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e FieldError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Field   string `json:"field"`
+		Source  string `json:"source"`
+		Name    string `json:"name,omitempty"`
+		Message string `json:"message"`
+	}{Field: e.Field, Source: e.Source, Name: e.Name, Message: e.Err.Error()})
+}
+
+// BindError is the error returned by Context.Bind if values couldn't be bound to
+// struct fields. It lists all fields that couldn't be bound.
+type BindError struct {
+	Fields []FieldError
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "binding failed: " + strings.Join(msgs, "; ")
+}
+
+// Bind decodes the request body into dst, a non-nil pointer, with the codec
+// registered for the Content-Type of the request (see Router.RegisterCodec),
+// by default JSON (application/json), XML (application/xml, text/xml) and
+// forms (application/x-www-form-urlencoded), or as multipart/form-data.
+// Form values are bound to struct fields tagged with `form:"name"`, files of
+// multipart forms to fields of type *multipart.FileHeader or
+// []*multipart.FileHeader.
+// Afterwards, struct fields tagged with `query:"name"`, `header:"name"` and
+// `param:"name"` are filled from query string, request headers and path
+// parameters respectively.
+//
+// Values are converted to the type of the field: strings, bools, integers,
+// floats, time.Duration, encoding.TextUnmarshaler (e.g. time.Time in RFC 3339
+// format), pointers to and slices of these. Untagged struct fields are
+// descended into.
+//
+// Bind returns an HTTPError with status code 400 (or 415 for unsupported content
+// types). If values couldn't be bound to fields, the HTTPError wraps a
+// BindError and lists the fields as details.
+func (c *Context) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic("dst must be a non-nil pointer")
+	}
+
+	var fields []FieldError
+
+	// decode the body (if any)
+	if c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+		if err := c.bindBody(dst, v.Elem(), &fields); err != nil {
+			return err
+		}
+	}
+
+	// fill the tagged struct fields
+	if v = v.Elem(); v.Kind() == reflect.Struct {
+		query := c.QueryValues()
+		bindValues(v, "", bindSource{tag: "query", values: func(name string) ([]string, bool) {
+			vs, ok := query[name]
+			return vs, ok
+		}}, &fields)
+		bindValues(v, "", bindSource{tag: "header", values: func(name string) ([]string, bool) {
+			vs, ok := c.Request.Header[textproto.CanonicalMIMEHeaderKey(name)]
+			return vs, ok
+		}}, &fields)
+		bindValues(v, "", c.Params.bindSource(), &fields)
+	}
+
+	if len(fields) > 0 {
+		return &HTTPError{Code: http.StatusBadRequest, Err: &BindError{Fields: fields}, Details: fields}
+	}
+	return nil
+}
+
+// bindBody decodes the request body into dst, v being the value dst points to.
+func (c *Context) bindBody(dst interface{}, v reflect.Value, fields *[]FieldError) error {
+	ctype := c.Request.Header.Get(HeaderContentType)
+	mediaType, _, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		return NewHTTPError(http.StatusUnsupportedMediaType, fmt.Errorf("invalid content type %q", ctype))
+	}
+
+	if mediaType == MIMEMultipartForm {
+		if err = c.parseMultipartForm(); err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Struct {
+			form := c.Request.MultipartForm
+			bindValues(v, "", bindSource{tag: "form", values: func(name string) ([]string, bool) {
+				vs, ok := form.Value[name]
+				return vs, ok
+			}, files: form.File}, fields)
+		}
+		return nil
+	}
+
+	codec := c.codec(mediaType)
+	if codec == nil {
+		return NewHTTPError(http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", mediaType))
+	}
+	if err = codec.Decode(c.Request.Body, dst); err != nil {
+		var ute *json.UnmarshalTypeError
+		var be *BindError
+		switch {
+		case errors.As(err, &ute):
+			*fields = append(*fields, FieldError{Field: ute.Field, Source: "body", Name: ute.Field, Err: err})
+		case errors.As(err, &be):
+			*fields = append(*fields, be.Fields...)
+		default:
+			return NewHTTPError(http.StatusBadRequest, err)
+		}
+	}
+	return nil
+}
+
+// bindSource is a source of values to bind to the struct fields with the tag.
+type bindSource struct {
+	tag    string
+	values func(name string) ([]string, bool)
+	files  map[string][]*multipart.FileHeader
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// bindValues fills the fields of the struct v which are tagged with the tag of
+// the source. Errors are appended to errs, field names prefixed with prefix.
+func bindValues(v reflect.Value, prefix string, src bindSource, errs *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { // unexported
+			continue
+		}
+		fv := v.Field(i)
+
+		name := sf.Tag.Get(src.tag)
+		if name == "-" {
+			continue
+		}
+
+		// descend into untagged structs
+		if name == "" {
+			if ft := sf.Type; ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(textUnmarshalerType) {
+				p := prefix + sf.Name + "."
+				if sf.Anonymous {
+					p = prefix
+				}
+				bindValues(fv, p, src, errs)
+			}
+			continue
+		}
+
+		// files of multipart forms
+		if src.files != nil && (sf.Type == fileHeaderType || sf.Type == fileHeaderSliceType) {
+			if fhs := src.files[name]; len(fhs) > 0 {
+				if sf.Type == fileHeaderType {
+					fv.Set(reflect.ValueOf(fhs[0]))
+				} else {
+					fv.Set(reflect.ValueOf(fhs))
+				}
+			}
+			continue
+		}
+
+		values, ok := src.values(name)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := setField(fv, values); err != nil {
+			*errs = append(*errs, FieldError{Field: prefix + sf.Name, Source: src.tag, Name: name, Err: err})
+		}
+	}
+}
+
+// setField sets the field to the given values converted to its type. Only
+// slices take more than the first value.
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setField(fv.Elem(), values)
+	}
+	if fv.Kind() == reflect.Slice && !fv.Addr().Type().Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setField(s.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	return setValue(fv, values[0])
+}
+
+// setValue sets the field to the given value converted to its type.
+func setValue(fv reflect.Value, value string) error {
+	if tu, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(value))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid boolean")
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return errors.New("invalid duration")
+			}
+			fv.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer")
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid unsigned integer")
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid number")
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindAddress struct {
+	Zip string `query:"zip" form:"zip"`
+}
+
+type bindTarget struct {
+	Name      string                `json:"name" xml:"name" form:"name"`
+	Age       int                   `json:"age" xml:"age" form:"age"`
+	Org       string                `param:"org"`
+	ID        uint64                `param:"id"`
+	Page      *int                  `query:"page"`
+	Tags      []string              `query:"tag"`
+	Since     time.Time             `query:"since"`
+	Timeout   time.Duration         `query:"timeout"`
+	Ratio     float32               `query:"ratio"`
+	Debug     bool                  `query:"debug"`
+	Token     string                `header:"X-Token"`
+	Avatar    *multipart.FileHeader `form:"avatar"`
+	Ignored   string                `query:"-"`
+	Address   bindAddress
+	unexposed string
+}
+
+func bindContext(method, target, contentType string, body *bytes.Buffer, ps Params) *Context {
+	c := &Context{Params: ps}
+	if body == nil {
+		c.Request = httptest.NewRequest(method, target, nil)
+	} else {
+		c.Request = httptest.NewRequest(method, target, body)
+		c.Request.Header.Set(HeaderContentType, contentType)
+	}
+	return c
+}
+
+func TestContextBind(t *testing.T) {
+	ps := Params{Param{"org", "heimdalr"}, Param{"id", "42"}}
+	since, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+	query := "?page=2&tag=a&tag=b&since=2020-01-02T03:04:05Z&timeout=1m&ratio=0.5&debug=true&zip=12345&Ignored=x"
+
+	var mp bytes.Buffer
+	mw := multipart.NewWriter(&mp)
+	_ = mw.WriteField("name", "gopher")
+	_ = mw.WriteField("age", "11")
+	fw, _ := mw.CreateFormFile("avatar", "gopher.png")
+	_, _ = fw.Write([]byte("png"))
+	_ = mw.Close()
+
+	bodies := []struct {
+		contentType string
+		body        string
+	}{
+		{MIMEApplicationJSONCharsetUTF8, `{"name":"gopher","age":11}`},
+		{MIMEApplicationXML, `<bindTarget><name>gopher</name><age>11</age></bindTarget>`},
+		{MIMEApplicationForm, `name=gopher&age=11`},
+		{mw.FormDataContentType(), mp.String()},
+	}
+	for _, b := range bodies {
+		c := bindContext(http.MethodPost, "/orgs/heimdalr/repos/42"+query, b.contentType, bytes.NewBufferString(b.body), ps)
+		c.Request.Header.Set("X-Token", "secret")
+
+		var dst bindTarget
+		if err := c.Bind(&dst); err != nil {
+			t.Fatalf("binding %s failed: %v", b.contentType, err)
+		}
+		page := 2
+		want := bindTarget{
+			Name: "gopher", Age: 11, Org: "heimdalr", ID: 42, Page: &page, Tags: []string{"a", "b"},
+			Since: since, Timeout: time.Minute, Ratio: 0.5, Debug: true, Token: "secret",
+			Address: bindAddress{Zip: "12345"},
+		}
+		if dst.Avatar != nil {
+			if dst.Avatar.Filename != "gopher.png" {
+				t.Errorf("wrong file bound: %s", dst.Avatar.Filename)
+			}
+			dst.Avatar = nil
+		} else if strings.HasPrefix(b.contentType, MIMEMultipartForm) {
+			t.Error("file not bound")
+		}
+		if !reflect.DeepEqual(dst, want) {
+			t.Errorf("binding %s: want %+v, got %+v", b.contentType, want, dst)
+		}
+	}
+}
+
+func TestContextBindErrors(t *testing.T) {
+	ps := Params{Param{"id", "-1"}}
+	c := bindContext(http.MethodPost, "/?page=two&debug=maybe", MIMEApplicationJSON, bytes.NewBufferString(`{"name":"gopher","age":"eleven"}`), ps)
+
+	var dst bindTarget
+	err := c.Bind(&dst)
+	var he *HTTPError
+	var be *BindError
+	if !errors.As(err, &he) || he.Code != http.StatusBadRequest || !errors.As(err, &be) {
+		t.Fatalf("expected HTTPError wrapping BindError, got %v", err)
+	}
+	var fields []string
+	for _, f := range be.Fields {
+		fields = append(fields, f.Source+":"+f.Field)
+	}
+	want := []string{"body:age", "query:Page", "query:Debug", "param:ID"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("wrong field errors: want %v, got %v", want, fields)
+	}
+	if dst.Name != "gopher" {
+		t.Error("valid fields not bound")
+	}
+
+	c = bindContext(http.MethodPost, "/", MIMEApplicationJSON, bytes.NewBufferString(`{"name":`), nil)
+	if err = c.Bind(&dst); !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed body, got %v", err)
+	}
+
+	c = bindContext(http.MethodPost, "/", "application/yaml", bytes.NewBufferString(`name: gopher`), nil)
+	if err = c.Bind(&dst); !errors.As(err, &he) || he.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for unsupported content type, got %v", err)
+	}
+
+	if recv := catchPanic(func() { _ = c.Bind(dst) }); recv == nil {
+		t.Error("binding to a non-pointer did not panic")
+	}
+}
@@ -0,0 +1,281 @@
+package httprouter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Codec encodes and decodes values in a content type. Codecs are used to bind
+// request bodies (see Context.Bind) and to render responses (see Context.Encode
+// and Context.Negotiate).
+type Codec interface {
+	// ContentType returns the content type of the encoded values, possibly with
+	// parameters (e.g. "application/json; charset=UTF-8").
+	ContentType() string
+	// Encode writes the encoding of v to w.
+	Encode(w io.Writer, v interface{}) error
+	// Decode reads the encoding of a value from r and stores it in the value
+	// pointed to by v.
+	Decode(r io.Reader, v interface{}) error
+}
+
+// JSONCodec encodes and decodes JSON with encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return MIMEApplicationJSONCharsetUTF8
+}
+
+func (JSONCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSONCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// XMLCodec encodes and decodes XML with encoding/xml. The encoding is prefixed
+// with the XML header.
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string {
+	return MIMEApplicationXMLCharsetUTF8
+}
+
+func (XMLCodec) Encode(w io.Writer, v interface{}) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (XMLCodec) Decode(r io.Reader, v interface{}) error {
+	if err := xml.NewDecoder(r).Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// FormCodec encodes and decodes URL encoded forms. Values are url.Values or
+// structs with fields tagged with `form:"name"` (see Context.Bind). Decoding
+// errors of struct fields are reported as *BindError.
+type FormCodec struct{}
+
+func (FormCodec) ContentType() string {
+	return MIMEApplicationForm
+}
+
+func (FormCodec) Encode(w io.Writer, v interface{}) error {
+	values, err := formValues(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, values.Encode())
+	return err
+}
+
+func (FormCodec) Decode(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+
+	switch dst := v.(type) {
+	case *url.Values:
+		*dst = values
+		return nil
+	case *map[string][]string:
+		*dst = values
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can't decode form into %T", v)
+	}
+	var fields []FieldError
+	bindValues(rv.Elem(), "", bindSource{tag: "form", values: func(name string) ([]string, bool) {
+		vs, ok := values[name]
+		return vs, ok
+	}}, &fields)
+	if len(fields) > 0 {
+		return &BindError{Fields: fields}
+	}
+	return nil
+}
+
+// formValues returns the form values of url.Values, maps of strings or string
+// slices and structs with fields tagged with `form:"name"`.
+func formValues(v interface{}) (url.Values, error) {
+	switch src := v.(type) {
+	case url.Values:
+		return src, nil
+	case map[string][]string:
+		return src, nil
+	case map[string]string:
+		values := make(url.Values, len(src))
+		for k, s := range src {
+			values.Set(k, s)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't encode %T as form", v)
+	}
+	values := make(url.Values)
+	if err := structFormValues(rv, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// structFormValues adds the values of the fields of the struct v tagged with
+// `form:"name"` to values, descending into untagged structs.
+func structFormValues(v reflect.Value, values url.Values) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { // unexported
+			continue
+		}
+		fv := v.Field(i)
+
+		name := sf.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if sf.Type.Kind() == reflect.Struct && !sf.Type.Implements(textMarshalerType) {
+				if err := structFormValues(fv, values); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice && !fv.Type().Implements(textMarshalerType) {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := formatValue(fv.Index(j))
+				if err != nil {
+					return err
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+		s, err := formatValue(fv)
+		if err != nil {
+			return err
+		}
+		values.Add(name, s)
+	}
+	return nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// formatValue formats the value for a form, the counterpart of setValue.
+func formatValue(v reflect.Value) (string, error) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// defaultCodecs are the codecs available without registration.
+var defaultCodecs = map[string]Codec{
+	MIMEApplicationJSON: JSONCodec{},
+	MIMEApplicationXML:  XMLCodec{},
+	MIMETextXML:         XMLCodec{},
+	MIMEApplicationForm: FormCodec{},
+}
+
+// RegisterCodec registers the given codec for its content type (without
+// parameters), replacing any codec registered for the content type before,
+// including the built-in JSON, XML and form codecs.
+//
+//	router.RegisterCodec(protobufCodec{}) // ContentType() returns MIMEApplicationProtobuf
+func (r *Router) RegisterCodec(codec Codec) {
+	typ := strings.ToLower(mediaType(codec.ContentType()))
+	if typ == "" {
+		panic("codec must have a content type")
+	}
+	if r.codecs == nil {
+		r.codecs = make(map[string]Codec)
+	}
+	r.codecs[typ] = codec
+}
+
+// Codec returns the codec for the given content type (parameters are ignored)
+// or nil if there is none.
+func (r *Router) Codec(contentType string) Codec {
+	typ := strings.ToLower(mediaType(contentType))
+	if codec, ok := r.codecs[typ]; ok {
+		return codec
+	}
+	return defaultCodecs[typ]
+}
+
+// codec returns the codec for the given content type, consulting the router of
+// the context if any.
+func (c *Context) codec(contentType string) Codec {
+	if c.router != nil {
+		return c.router.Codec(contentType)
+	}
+	return defaultCodecs[strings.ToLower(mediaType(contentType))]
+}
+
+// errNoCodec is returned when rendering values in content types without codec.
+var errNoCodec = errors.New("no codec for content type")
+
+// Encode renders the given value with the given status code, encoded with the
+// codec registered for the given content type (see Router.RegisterCodec).
+// If the value can't be encoded, the error is returned and nothing is written.
+func (c *Context) Encode(code int, contentType string, v interface{}) error {
+	codec := c.codec(contentType)
+	if codec == nil {
+		return fmt.Errorf("%w %q", errNoCodec, contentType)
+	}
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, v); err != nil {
+		return err
+	}
+	return c.Blob(code, codec.ContentType(), buf.Bytes())
+}
@@ -0,0 +1,158 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upperCodec is a toy codec for strings, encoding them upper case.
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string {
+	return "text/x-upper; charset=utf-8"
+}
+
+func (upperCodec) Encode(w io.Writer, v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return errors.New("not a string")
+	}
+	_, err := io.WriteString(w, strings.ToUpper(s))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s, ok := v.(*string)
+	if !ok {
+		return errors.New("not a string")
+	}
+	*s = strings.ToLower(string(b))
+	return nil
+}
+
+func TestFormCodec(t *testing.T) {
+	type form struct {
+		Name  string    `form:"name"`
+		Tags  []string  `form:"tag"`
+		Age   *int      `form:"age"`
+		Since time.Time `form:"since"`
+		Skip  string    `form:"-"`
+		Inner struct {
+			Zip string `form:"zip"`
+		}
+	}
+	age := 11
+	src := form{Name: "gopher", Tags: []string{"a", "b"}, Age: &age, Skip: "x"}
+	src.Since, _ = time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+	src.Inner.Zip = "12345"
+
+	var buf bytes.Buffer
+	if err := (FormCodec{}).Encode(&buf, src); err != nil {
+		t.Fatalf("encoding failed: %v", err)
+	}
+	want := "age=11&name=gopher&since=2020-01-02T03%3A04%3A05Z&tag=a&tag=b&zip=12345"
+	if buf.String() != want {
+		t.Errorf("wrong encoding:\n got %q\nwant %q", buf.String(), want)
+	}
+
+	var dst form
+	if err := (FormCodec{}).Decode(strings.NewReader(want), &dst); err != nil {
+		t.Fatalf("decoding failed: %v", err)
+	}
+	src.Skip = ""
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("wrong decoding:\n got %+v\nwant %+v", dst, src)
+	}
+
+	var values url.Values
+	if err := (FormCodec{}).Decode(strings.NewReader("a=1&a=2"), &values); err != nil || !reflect.DeepEqual(values, url.Values{"a": {"1", "2"}}) {
+		t.Errorf("wrong decoding into url.Values: %v, %v", values, err)
+	}
+
+	err := (FormCodec{}).Decode(strings.NewReader("age=old"), &dst)
+	var be *BindError
+	if !errors.As(err, &be) || len(be.Fields) != 1 || be.Fields[0].Field != "Age" {
+		t.Errorf("expected BindError for field Age, got %v", err)
+	}
+}
+
+func TestRouterCodecs(t *testing.T) {
+	router := New()
+	router.RegisterCodec(upperCodec{})
+
+	if router.Codec("text/x-upper") == nil || router.Codec("TEXT/X-UPPER; charset=utf-8") == nil {
+		t.Error("registered codec not found")
+	}
+	if _, ok := router.Codec(MIMEApplicationJSONCharsetUTF8).(JSONCodec); !ok {
+		t.Error("built-in JSON codec not found")
+	}
+	if router.Codec(MIMEApplicationMsgpack) != nil {
+		t.Error("unexpected codec for msgpack")
+	}
+
+	router.POST("/echo", HandleE(func(c *Context) error {
+		var s string
+		if err := c.Bind(&s); err != nil {
+			return err
+		}
+		return c.Negotiate(http.StatusOK, s, "text/x-upper", MIMEApplicationJSON)
+	}).Handle())
+
+	tests := []struct {
+		contentType string
+		accept      string
+		status      int
+		body        string
+	}{
+		{"text/x-upper", "text/x-upper", http.StatusOK, "GOPHER"},
+		{"text/x-upper", MIMEApplicationJSON, http.StatusOK, "\"gopher\"\n"},
+		{MIMEApplicationJSON, "*/*", http.StatusOK, "GOPHER"},
+		{MIMEApplicationMsgpack, "*/*", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, test := range tests {
+		body := "Gopher"
+		if test.contentType == MIMEApplicationJSON {
+			body = `"gopher"`
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, test.contentType)
+		req.Header.Set(HeaderAccept, test.accept)
+		router.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s -> %s: wrong status code: want %d, got %d", test.contentType, test.accept, test.status, w.Code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s -> %s: wrong body: want %q, got %q", test.contentType, test.accept, test.body, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil), router: router}
+	c.Response = c.wrapResponseWriter(w)
+	if err := c.Encode(http.StatusOK, MIMEApplicationMsgpack, "x"); !errors.Is(err, errNoCodec) {
+		t.Errorf("expected errNoCodec, got %v", err)
+	}
+	if err := c.Encode(http.StatusOK, "text/x-upper", 42); err == nil || c.Response.Written() {
+		t.Errorf("expected encoding error without response, got %v", err)
+	}
+	if err := c.Encode(http.StatusCreated, "text/x-upper", "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != "HI" || w.Header().Get(HeaderContentType) != "text/x-upper; charset=utf-8" {
+		t.Errorf("wrong response: %d %q %q", w.Code, w.Header().Get(HeaderContentType), w.Body.String())
+	}
+}
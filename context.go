@@ -3,31 +3,299 @@ package httprouter
 // copy of https://github.com/labstack/echo/blob/4c2fd1fb042b122e2f96830ddb58aee6c9f90bf3/context.go
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"github.com/rs/zerolog"
+	"io"
+	"mime"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Context struct {
-	Request      *http.Request
-	Response     ResponseWriter
-	Params       Params
-	Store        map[string]interface{}
+	Request  *http.Request
+	Response ResponseWriter
+	Params   Params
+	// CanonicalPath is the path the request was dispatched on. It only differs
+	// from Request.URL.Path if the router corrected the path (see
+	// Router.ServeFixedPath).
+	CanonicalPath string
+	// OriginalPath is the request path as received, i.e. before it was
+	// rewritten by Router.PathNormalizers.
+	OriginalPath string
+	// Route is the route the request was dispatched to.
+	Route *Route
+	// Store holds request-scoped values, see Set and Get
+	Store        map[interface{}]interface{}
 	Logger       zerolog.Logger
 	ErrorHandler func(status int, err error, c *Context)
 	lock         sync.RWMutex
+
+	// response writers wrapping the http.ResponseWriter, embedded to avoid
+	// allocations
+	rw   responseWriter
+	rwcn responseWriterCloseNotifer
+
+	// the router serving the request
+	router *Router
+
+	// the ID of the request, see RequestID
+	requestID string
+
+	// the session manager and the session loaded by Session
+	sessions *Sessions
+	session  *Session
+
+	// functions called when the context object is released, see onRelease
+	cleanups []func()
+
+	// the parsed query string, see QueryValues
+	query url.Values
+
+	// set if the context object was released and poisoned (see ContextDebug)
+	released bool
 }
 
+// ContextDebug enables the poisoning of released context objects. Instead of
+// being returned to the pool, released context objects are rendered unusable,
+// such that handlers retaining a *Context after they returned panic on its
+// next use. Meant for testing and debugging only.
+var ContextDebug = false
+
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		c := new(Context)
+		c.rwcn.responseWriter = &c.rw
+		return c
+	},
+}
+
+// AcquireContextObject returns an empty context object from the pool.
 func AcquireContextObject() *Context {
-	// TODO: acquire from pool
-	return &Context{}
+	return contextPool.Get().(*Context)
 }
 
+// ReleaseContextObject resets the given context object and returns it to the
+// pool. The context object must not be used afterwards.
 func ReleaseContextObject(c *Context) {
-	// TODO: release to pool
+	if c.released {
+		panic(releasedContextPanic)
+	}
+	c.reset()
+	if ContextDebug {
+		c.poison()
+		return
+	}
+	contextPool.Put(c)
+}
+
+const releasedContextPanic = "httprouter: use of released Context"
+
+// reset clears all fields of the context object, keeping the store map and the
+// response writers for reuse.
+func (c *Context) reset() {
+	for i := len(c.cleanups) - 1; i >= 0; i-- {
+		c.cleanups[i]()
+		c.cleanups[i] = nil
+	}
+	c.cleanups = c.cleanups[:0]
+	c.Request = nil
+	c.Response = nil
+	c.Params = nil
+	c.CanonicalPath = ""
+	c.OriginalPath = ""
+	c.Route = nil
+	for k := range c.Store {
+		delete(c.Store, k)
+	}
+	c.Logger = zerolog.Logger{}
+	c.ErrorHandler = nil
+	c.router = nil
+	c.requestID = ""
+	c.sessions = nil
+	c.session = nil
+	c.query = nil
+	c.rw.reset(nil)
+}
+
+// onRelease registers a function to be called when the context object is
+// released, i.e. after the handler returned (e.g. to remove temporary files).
+// Functions are called in reverse order of registration.
+func (c *Context) onRelease(fn func()) {
+	c.cleanups = append(c.cleanups, fn)
+}
+
+// poison renders the context object unusable.
+func (c *Context) poison() {
+	c.released = true
+	c.Response = releasedResponseWriter{}
+	c.Store = nil
+}
+
+// wrapResponseWriter wraps the given http.ResponseWriter into the response
+// writer embedded in the context object.
+func (c *Context) wrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	c.rw.reset(w)
+	if _, ok := w.(http.CloseNotifier); ok {
+		return &c.rwcn
+	}
+	return &c.rw
+}
+
+// Set stores the given value under the given key in the context object.
+// Keys may be of any comparable type; using an unexported key type (e.g.
+// "type principalKey struct{}") prevents collisions between packages.
+// It is safe to call Set (and all other store methods) from multiple
+// goroutines, as long as they finish before the handler returns.
+func (c *Context) Set(key, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.released {
+		panic(releasedContextPanic)
+	}
+	if c.Store == nil {
+		c.Store = make(map[interface{}]interface{})
+	}
+	c.Store[key] = value
+}
+
+// Get returns the value stored under the given key, and whether there is one.
+func (c *Context) Get(key interface{}) (value interface{}, exists bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.released {
+		panic(releasedContextPanic)
+	}
+	value, exists = c.Store[key]
+	return
+}
+
+// MustGet returns the value stored under the given key and panics if there is
+// none.
+func (c *Context) MustGet(key interface{}) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("key %v does not exist", key))
+}
+
+// Delete removes the value stored under the given key (if any).
+func (c *Context) Delete(key interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.released {
+		panic(releasedContextPanic)
+	}
+	delete(c.Store, key)
+}
+
+// Keys returns the keys of all stored values, in no particular order.
+func (c *Context) Keys() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.released {
+		panic(releasedContextPanic)
+	}
+	keys := make([]interface{}, 0, len(c.Store))
+	for key := range c.Store {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// GetString returns the value stored under the given key if it is a string,
+// otherwise "".
+func (c *Context) GetString(key interface{}) (s string) {
+	if value, ok := c.Get(key); ok {
+		s, _ = value.(string)
+	}
+	return
+}
+
+// GetBool returns the value stored under the given key if it is a bool,
+// otherwise false.
+func (c *Context) GetBool(key interface{}) (b bool) {
+	if value, ok := c.Get(key); ok {
+		b, _ = value.(bool)
+	}
+	return
+}
+
+// GetInt returns the value stored under the given key if it is an int,
+// otherwise 0.
+func (c *Context) GetInt(key interface{}) (i int) {
+	if value, ok := c.Get(key); ok {
+		i, _ = value.(int)
+	}
+	return
+}
+
+// GetInt64 returns the value stored under the given key if it is an int64,
+// otherwise 0.
+func (c *Context) GetInt64(key interface{}) (i int64) {
+	if value, ok := c.Get(key); ok {
+		i, _ = value.(int64)
+	}
+	return
+}
+
+// GetFloat64 returns the value stored under the given key if it is a float64,
+// otherwise 0.
+func (c *Context) GetFloat64(key interface{}) (f float64) {
+	if value, ok := c.Get(key); ok {
+		f, _ = value.(float64)
+	}
+	return
+}
+
+// GetTime returns the value stored under the given key if it is a time.Time,
+// otherwise the zero time.
+func (c *Context) GetTime(key interface{}) (t time.Time) {
+	if value, ok := c.Get(key); ok {
+		t, _ = value.(time.Time)
+	}
+	return
+}
+
+// GetDuration returns the value stored under the given key if it is a
+// time.Duration, otherwise 0.
+func (c *Context) GetDuration(key interface{}) (d time.Duration) {
+	if value, ok := c.Get(key); ok {
+		d, _ = value.(time.Duration)
+	}
+	return
+}
+
+// GetStringSlice returns the value stored under the given key if it is a
+// []string, otherwise nil.
+func (c *Context) GetStringSlice(key interface{}) (ss []string) {
+	if value, ok := c.Get(key); ok {
+		ss, _ = value.([]string)
+	}
+	return
+}
+
+// RequestID returns the ID of the request, i.e. the value of the X-Request-ID
+// request header or, if there is none, a randomly generated ID.
+func (c *Context) RequestID() string {
+	if c.requestID == "" {
+		if c.requestID = headerValue(c.Request.Header, canonicalXRequestID); c.requestID == "" {
+			var b [16]byte
+			_, _ = rand.Read(b[:])
+			c.requestID = hex.EncodeToString(b[:])
+		}
+	}
+	return c.requestID
 }
 
 func (c *Context) RealIP() string {
@@ -38,52 +306,313 @@ func (c *Context) RealIP() string {
 		}
 		return ip
 	}
-	if ip := c.Request.Header.Get(HeaderXRealIP); ip != "" {
+	if ip := headerValue(c.Request.Header, canonicalXRealIP); ip != "" {
 		return ip
 	}
+	if c.Request.RemoteAddr == "" {
+		return ""
+	}
 	ra, _, _ := net.SplitHostPort(c.Request.RemoteAddr)
 	return ra
 }
 
-func (c *Context) JSON(code int, i interface{})  {
-	enc := json.NewEncoder(c.Response)
-	c.Response.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
-	c.Response.WriteHeader(code)
-	err := enc.Encode(i)
+// Canonical forms of header names which aren't canonical, such that they can be
+// looked up without allocations (see headerValue).
+var (
+	canonicalXRequestID = http.CanonicalHeaderKey(HeaderXRequestID)
+	canonicalXRealIP    = http.CanonicalHeaderKey(HeaderXRealIP)
+)
+
+// headerValue returns the first value of the header with the given canonical
+// name, like http.Header.Get without canonicalizing the name.
+func headerValue(h http.Header, canonicalKey string) string {
+	if v := h[canonicalKey]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// IsTLS reports whether the request was received via TLS.
+func (c *Context) IsTLS() bool {
+	return c.Request.TLS != nil
+}
+
+// Scheme returns the scheme of the request, "http" or "https", taking the
+// headers set by proxies (X-Forwarded-Proto etc.) into account.
+func (c *Context) Scheme() string {
+	if c.IsTLS() {
+		return "https"
+	}
+	if scheme := c.Request.Header.Get(HeaderXForwardedProto); scheme != "" {
+		return scheme
+	}
+	if scheme := c.Request.Header.Get(HeaderXForwardedProtocol); scheme != "" {
+		return scheme
+	}
+	if ssl := c.Request.Header.Get(HeaderXForwardedSsl); ssl == "on" {
+		return "https"
+	}
+	if scheme := c.Request.Header.Get(HeaderXUrlScheme); scheme != "" {
+		return scheme
+	}
+	return "http"
+}
+
+// String renders the given string as text/plain with the given status code.
+func (c *Context) String(code int, s string) error {
+	return c.Blob(code, MIMETextPlainCharsetUTF8, []byte(s))
+}
+
+// HTML renders the given string as text/html with the given status code.
+func (c *Context) HTML(code int, html string) error {
+	return c.HTMLBlob(code, []byte(html))
+}
+
+// HTMLBlob renders the given bytes as text/html with the given status code.
+func (c *Context) HTMLBlob(code int, b []byte) error {
+	return c.Blob(code, MIMETextHTMLCharsetUTF8, b)
+}
+
+// JSON renders the given value as application/json with the given status code.
+// If the value can't be encoded, the error is returned and nothing is written.
+func (c *Context) JSON(code int, i interface{}) error {
+	b, err := json.Marshal(i)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return c.JSONBlob(code, append(b, '\n'))
 }
 
+// JSONPretty renders the given value as application/json indented with the
+// given indent with the given status code.
 func (c *Context) JSONPretty(code int, i interface{}, indent string) error {
-	enc := json.NewEncoder(c.Response)
-	enc.SetIndent("", indent)
-	c.Response.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	b, err := json.MarshalIndent(i, "", indent)
+	if err != nil {
+		return err
+	}
+	return c.JSONBlob(code, append(b, '\n'))
+}
+
+// JSONBlob renders the given bytes as application/json with the given status
+// code.
+func (c *Context) JSONBlob(code int, b []byte) error {
+	return c.Blob(code, MIMEApplicationJSONCharsetUTF8, b)
+}
+
+// JSONP renders the given value as JSON padded with the given callback, i.e.
+// as application/javascript calling the callback with the value. The callback
+// must be a JavaScript identifier or a dotted path of identifiers (e.g.
+// "widgets.update"), otherwise an HTTPError with status code 400 is returned.
+// The script is prefixed with an empty comment and served with
+// "X-Content-Type-Options: nosniff" to mitigate content sniffing attacks.
+// If the callback is empty, the value is rendered as plain JSON.
+func (c *Context) JSONP(code int, callback string, i interface{}) error {
+	if callback == "" {
+		return c.JSON(code, i)
+	}
+	if !validCallback(callback) {
+		return NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid JSONP callback %q", callback))
+	}
+	b, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set(HeaderXContentTypeOptions, "nosniff")
+	buf := make([]byte, 0, len(callback)+len(b)+8)
+	buf = append(buf, "/**/"...)
+	buf = append(buf, callback...)
+	buf = append(buf, '(')
+	buf = append(buf, b...)
+	buf = append(buf, ");"...)
+	return c.Blob(code, MIMEApplicationJavaScriptCharsetUTF8, buf)
+}
+
+// maxCallbackLength is the maximum length of JSONP callback names.
+const maxCallbackLength = 128
+
+// validCallback reports whether the given JSONP callback is a dot separated
+// list of JavaScript identifiers (restricted to ASCII letters, digits, '_' and
+// '$').
+func validCallback(callback string) bool {
+	if len(callback) > maxCallbackLength {
+		return false
+	}
+	for _, ident := range strings.Split(callback, ".") {
+		if ident == "" {
+			return false
+		}
+		for i := 0; i < len(ident); i++ {
+			switch b := ident[i]; {
+			case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b == '_', b == '$':
+			case b >= '0' && b <= '9' && i > 0:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// XML renders the given value as application/xml with the given status code.
+// If the value can't be encoded, the error is returned and nothing is written.
+func (c *Context) XML(code int, i interface{}) error {
+	b, err := xml.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return c.XMLBlob(code, b)
+}
+
+// XMLPretty renders the given value as application/xml indented with the given
+// indent with the given status code.
+func (c *Context) XMLPretty(code int, i interface{}, indent string) error {
+	b, err := xml.MarshalIndent(i, "", indent)
+	if err != nil {
+		return err
+	}
+	return c.XMLBlob(code, b)
+}
+
+// XMLBlob renders the given bytes, prefixed with the XML header, as
+// application/xml with the given status code.
+func (c *Context) XMLBlob(code int, b []byte) error {
+	return c.Blob(code, MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), b...))
+}
+
+// Blob renders the given bytes with the given content type and status code.
+func (c *Context) Blob(code int, contentType string, b []byte) error {
+	c.Response.Header().Set(HeaderContentType, contentType)
 	c.Response.WriteHeader(code)
-	return enc.Encode(i)
+	_, err := c.Response.Write(b)
+	return err
 }
 
-func (c *Context) JSONBlob(code int, b []byte) (err error) {
-	c.Response.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+// Stream copies the given reader to the response with the given content type
+// and status code.
+func (c *Context) Stream(code int, contentType string, r io.Reader) error {
+	c.Response.Header().Set(HeaderContentType, contentType)
 	c.Response.WriteHeader(code)
-	_, err = c.Response.Write(b)
-	return
+	_, err := io.Copy(c.Response, r)
+	return err
+}
+
+// File serves the file with the given path (see http.ServeContent), the
+// index.html of directories. If the file doesn't exist, an HTTPError with
+// status code 404 is returned.
+func (c *Context) File(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return c.fileError(err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		file = filepath.Join(file, "index.html")
+		ff, err := os.Open(file)
+		if err != nil {
+			return c.fileError(err)
+		}
+		defer ff.Close()
+		if fi, err = ff.Stat(); err != nil {
+			return err
+		}
+		f = ff
+	}
+	http.ServeContent(c.Response, c.Request, fi.Name(), fi.ModTime(), f)
+	return nil
+}
+
+// fileError converts errors opening files to HTTPErrors. The cause is logged
+// only, as it reveals the path of the file.
+func (c *Context) fileError(err error) error {
+	if os.IsNotExist(err) {
+		c.Logger.Debug().Err(err).Msg("file not found")
+		return NewHTTPError(http.StatusNotFound, nil)
+	}
+	if os.IsPermission(err) {
+		c.Logger.Warn().Err(err).Msg("file not accessible")
+		return NewHTTPError(http.StatusForbidden, nil)
+	}
+	return err
+}
+
+// Attachment serves the file with the given path (see File) as attachment to
+// be downloaded with the given file name.
+func (c *Context) Attachment(file, name string) error {
+	return c.contentDisposition(file, name, "attachment")
 }
 
-func (c *Context) NoContent(code int) {
+// Inline serves the file with the given path (see File) to be displayed inline
+// with the given file name.
+func (c *Context) Inline(file, name string) error {
+	return c.contentDisposition(file, name, "inline")
+}
+
+func (c *Context) contentDisposition(file, name, dispositionType string) error {
+	c.Response.Header().Set(HeaderContentDisposition, mime.FormatMediaType(dispositionType, map[string]string{"filename": name}))
+	return c.File(file)
+}
+
+// NoContent writes the given status code without body.
+func (c *Context) NoContent(code int) error {
 	c.Response.WriteHeader(code)
-	_, _ = c.Response.Write(nil)
-	return
+	return nil
 }
 
-func (c *Context) Redirect(code int, url string) {
-	if code < 300 || code > 308 {
-		panic("invalid redirect code")
+// ErrInvalidRedirectCode is returned by Context.Redirect for status codes
+// which aren't redirect codes.
+var ErrInvalidRedirectCode = errors.New("invalid redirect code")
+
+// Redirect redirects the request to the given URL with the given status code,
+// which must be one of 300-303, 307 and 308. For other status codes
+// ErrInvalidRedirectCode is returned and nothing is written.
+func (c *Context) Redirect(code int, url string) error {
+	switch code {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return ErrInvalidRedirectCode
 	}
 	c.Response.Header().Set(HeaderLocation, url)
 	c.Response.WriteHeader(code)
+	return nil
+}
+
+// errorStatus returns the status code for the given error (see Error).
+func (c *Context) errorStatus(err error) int {
+	if status := errorStatus(err); status != 0 {
+		return status
+	}
+	if c.router != nil {
+		if p, _ := c.router.mapError(err); p != nil && p.Status != 0 {
+			return p.Status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem renders the given problem details as application/problem+json with
+// the status code of the problem.
+func (c *Context) Problem(p *Problem) {
+	writeProblem(c.Response, p)
 }
 
+// Error hands the given status code and error to the ErrorHandler of the
+// context object (DefaultErrorHandler if not set) to render an error response.
+// If the code is 0, the status code is determined from the error: the code of
+// an HTTPError, the status of a Problem or of the problem registered for the
+// error (see Router.MapError), 500 otherwise.
 func (c *Context) Error(code int, err error) {
-	c.Response.WriteHeaderError(code, err)
+	if code == 0 {
+		code = c.errorStatus(err)
+	}
+	if c.ErrorHandler != nil {
+		c.ErrorHandler(code, err, c)
+		return
+	}
+	DefaultErrorHandler(code, err, c)
 }
@@ -0,0 +1,288 @@
+package httprouter
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContextPool(t *testing.T) {
+	c := AcquireContextObject()
+	c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	c.Response = c.wrapResponseWriter(new(mockResponseWriter))
+	c.Response.WriteHeader(http.StatusTeapot)
+	c.Params = Params{Param{"name", "gopher"}}
+	c.CanonicalPath = "/"
+	c.OriginalPath = "/"
+	c.Set("key", "value")
+	c.ErrorHandler = func(int, error, *Context) {}
+	ReleaseContextObject(c)
+
+	if c.Request != nil || c.Response != nil || c.Params != nil || c.CanonicalPath != "" ||
+		c.OriginalPath != "" || len(c.Store) != 0 || c.ErrorHandler != nil || c.rw.Status() != 0 {
+		t.Errorf("context object not reset: %+v", c)
+	}
+}
+
+func TestContextDebug(t *testing.T) {
+	ContextDebug = true
+	defer func() { ContextDebug = false }()
+
+	var retained *Context
+	router := New()
+	router.GET("/", func(c *Context) {
+		retained = c
+	})
+
+	w := new(mockResponseWriter)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	router.ServeHTTP(w, r)
+
+	if recv := catchPanic(func() {
+		retained.Response.WriteHeader(http.StatusOK)
+	}); recv != releasedContextPanic {
+		t.Errorf("using a released context object did not panic, got %v", recv)
+	}
+	if recv := catchPanic(func() {
+		retained.Set("key", "value")
+	}); recv != releasedContextPanic {
+		t.Errorf("using a released context object did not panic, got %v", recv)
+	}
+	if recv := catchPanic(func() {
+		ReleaseContextObject(retained)
+	}); recv != releasedContextPanic {
+		t.Errorf("releasing a context object twice did not panic, got %v", recv)
+	}
+}
+
+type principalKey struct{}
+
+func TestContextStore(t *testing.T) {
+	c := AcquireContextObject()
+	defer ReleaseContextObject(c)
+
+	now := time.Now()
+	c.Set("string", "value")
+	c.Set("int", 42)
+	c.Set("time", now)
+	c.Set(principalKey{}, "gopher")
+
+	if value, exists := c.Get("string"); !exists || value != "value" {
+		t.Errorf("Get: got %v, %v", value, exists)
+	}
+	if value, exists := c.Get("nope"); exists || value != nil {
+		t.Errorf("Get of missing key: got %v, %v", value, exists)
+	}
+	if value := c.MustGet(principalKey{}); value != "gopher" {
+		t.Errorf("MustGet: got %v", value)
+	}
+	if recv := catchPanic(func() { c.MustGet("nope") }); recv == nil {
+		t.Error("MustGet of missing key did not panic")
+	}
+	if s := c.GetString("string"); s != "value" {
+		t.Errorf("GetString: got %q", s)
+	}
+	if s := c.GetString("int"); s != "" {
+		t.Errorf("GetString of int: got %q", s)
+	}
+	if i := c.GetInt("int"); i != 42 {
+		t.Errorf("GetInt: got %d", i)
+	}
+	if tm := c.GetTime("time"); !tm.Equal(now) {
+		t.Errorf("GetTime: got %v", tm)
+	}
+
+	c.Delete("time")
+	var keys []string
+	for _, key := range c.Keys() {
+		if s, ok := key.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "int" || keys[1] != "string" || len(c.Keys()) != 3 {
+		t.Errorf("Keys: got %v", c.Keys())
+	}
+
+	// access from goroutines spawned by the handler
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Set(strconv.Itoa(i), i)
+			c.GetInt(strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+	if n := len(c.Keys()); n != 13 {
+		t.Errorf("expected 13 keys, got %d", n)
+	}
+}
+
+func TestContextRenderers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hello.txt")
+	if err = ioutil.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<p>index</p>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type message struct {
+		XMLName xml.Name `xml:"message" json:"-"`
+		Text    string   `xml:"text" json:"text"`
+	}
+	msg := message{Text: "hello"}
+
+	tests := []struct {
+		name        string
+		render      func(c *Context) error
+		status      int
+		contentType string
+		disposition string
+		body        string
+	}{
+		{"String", func(c *Context) error { return c.String(http.StatusOK, "hello") }, http.StatusOK, MIMETextPlainCharsetUTF8, "", "hello"},
+		{"HTML", func(c *Context) error { return c.HTML(http.StatusOK, "<p>hello</p>") }, http.StatusOK, MIMETextHTMLCharsetUTF8, "", "<p>hello</p>"},
+		{"JSON", func(c *Context) error { return c.JSON(http.StatusCreated, msg) }, http.StatusCreated, MIMEApplicationJSONCharsetUTF8, "", "{\"text\":\"hello\"}\n"},
+		{"JSONPretty", func(c *Context) error { return c.JSONPretty(http.StatusOK, msg, "  ") }, http.StatusOK, MIMEApplicationJSONCharsetUTF8, "", "{\n  \"text\": \"hello\"\n}\n"},
+		{"XML", func(c *Context) error { return c.XML(http.StatusOK, msg) }, http.StatusOK, MIMEApplicationXMLCharsetUTF8, "", xml.Header + "<message><text>hello</text></message>"},
+		{"XMLPretty", func(c *Context) error { return c.XMLPretty(http.StatusOK, msg, " ") }, http.StatusOK, MIMEApplicationXMLCharsetUTF8, "", xml.Header + "<message>\n <text>hello</text>\n</message>"},
+		{"Blob", func(c *Context) error { return c.Blob(http.StatusOK, MIMEOctetStream, []byte{1, 2}) }, http.StatusOK, MIMEOctetStream, "", "\x01\x02"},
+		{"Stream", func(c *Context) error { return c.Stream(http.StatusOK, MIMETextPlain, strings.NewReader("stream")) }, http.StatusOK, MIMETextPlain, "", "stream"},
+		{"File", func(c *Context) error { return c.File(file) }, http.StatusOK, "text/plain; charset=utf-8", "", "hello"},
+		{"FileIndex", func(c *Context) error { return c.File(dir) }, http.StatusOK, "text/html; charset=utf-8", "", "<p>index</p>"},
+		{"Attachment", func(c *Context) error { return c.Attachment(file, "greeting.txt") }, http.StatusOK, "text/plain; charset=utf-8", "attachment; filename=greeting.txt", "hello"},
+		{"Inline", func(c *Context) error { return c.Inline(file, "grüße.txt") }, http.StatusOK, "text/plain; charset=utf-8", "inline; filename*=utf-8''gr%C3%BC%C3%9Fe.txt", "hello"},
+		{"NoContent", func(c *Context) error { return c.NoContent(http.StatusNoContent) }, http.StatusNoContent, "", "", ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		c.Response = c.wrapResponseWriter(w)
+		if err := test.render(c); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if w.Code != test.status {
+			t.Errorf("%s: wrong status code: want %d, got %d", test.name, test.status, w.Code)
+		}
+		if got := w.Header().Get(HeaderContentType); got != test.contentType {
+			t.Errorf("%s: wrong content type: want %q, got %q", test.name, test.contentType, got)
+		}
+		if got := w.Header().Get(HeaderContentDisposition); got != test.disposition {
+			t.Errorf("%s: wrong content disposition: want %q, got %q", test.name, test.disposition, got)
+		}
+		if got := w.Body.String(); got != test.body {
+			t.Errorf("%s: wrong body: want %q, got %q", test.name, test.body, got)
+		}
+	}
+
+	// errors are returned instead of written
+	w := httptest.NewRecorder()
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.Response = c.wrapResponseWriter(w)
+	if err := c.JSON(http.StatusOK, func() {}); err == nil {
+		t.Error("no error for value which can't be encoded")
+	}
+	if c.Response.Written() {
+		t.Error("response written despite encoding error")
+	}
+	err = c.File(filepath.Join(dir, "missing.txt"))
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Code != http.StatusNotFound {
+		t.Errorf("expected HTTPError with status code 404 for missing file, got %v", err)
+	}
+	if strings.Contains(err.Error(), dir) {
+		t.Errorf("error reveals the path of the file: %v", err)
+	}
+}
+
+func TestContextRedirect(t *testing.T) {
+	for _, code := range []int{http.StatusMovedPermanently, http.StatusSeeOther, http.StatusPermanentRedirect} {
+		w := httptest.NewRecorder()
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		c.Response = c.wrapResponseWriter(w)
+		if err := c.Redirect(code, "/new"); err != nil || w.Code != code || w.Header().Get(HeaderLocation) != "/new" {
+			t.Errorf("redirect %d: got %d %q, %v", code, w.Code, w.Header().Get(HeaderLocation), err)
+		}
+	}
+	for _, code := range []int{http.StatusOK, http.StatusNotModified, http.StatusUseProxy, 306, http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		c.Response = c.wrapResponseWriter(w)
+		if err := c.Redirect(code, "/new"); err != ErrInvalidRedirectCode {
+			t.Errorf("redirect %d: expected ErrInvalidRedirectCode, got %v", code, err)
+		}
+		if c.Response.Written() {
+			t.Errorf("redirect %d: response written", code)
+		}
+	}
+}
+
+func TestContextJSONP(t *testing.T) {
+	tests := []struct {
+		callback    string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"callback", http.StatusOK, MIMEApplicationJavaScriptCharsetUTF8, `/**/callback({"text":"hi"});`},
+		{"jQuery_123.$cb", http.StatusOK, MIMEApplicationJavaScriptCharsetUTF8, `/**/jQuery_123.$cb({"text":"hi"});`},
+		{"", http.StatusOK, MIMEApplicationJSONCharsetUTF8, "{\"text\":\"hi\"}\n"},
+		{"alert(1);cb", http.StatusBadRequest, "", ""},
+		{"1cb", http.StatusBadRequest, "", ""},
+		{"cb.", http.StatusBadRequest, "", ""},
+		{"cb[0]", http.StatusBadRequest, "", ""},
+		{strings.Repeat("a", maxCallbackLength+1), http.StatusBadRequest, "", ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		c.Response = c.wrapResponseWriter(w)
+		err := c.JSONP(http.StatusOK, test.callback, map[string]string{"text": "hi"})
+		if test.status != http.StatusOK {
+			var he *HTTPError
+			if !errors.As(err, &he) || he.Code != test.status {
+				t.Errorf("%q: expected HTTPError with status code %d, got %v", test.callback, test.status, err)
+			}
+			if c.Response.Written() {
+				t.Errorf("%q: response written for invalid callback", test.callback)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.callback, err)
+			continue
+		}
+		if got := w.Header().Get(HeaderContentType); got != test.contentType {
+			t.Errorf("%q: wrong content type: want %q, got %q", test.callback, test.contentType, got)
+		}
+		nosniff := ""
+		if test.callback != "" {
+			nosniff = "nosniff"
+		}
+		if got := w.Header().Get(HeaderXContentTypeOptions); got != nosniff {
+			t.Errorf("%q: wrong X-Content-Type-Options: want %q, got %q", test.callback, nosniff, got)
+		}
+		if got := w.Body.String(); got != test.body {
+			t.Errorf("%q: wrong body: want %q, got %q", test.callback, test.body, got)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package httprouter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Cookie returns the request cookie with the given name, or
+// http.ErrNoCookie if there is none.
+func (c *Context) Cookie(name string) (*http.Cookie, error) {
+	return c.Request.Cookie(name)
+}
+
+// Cookies returns the cookies of the request.
+func (c *Context) Cookies() []*http.Cookie {
+	return c.Request.Cookies()
+}
+
+// NewCookie returns a new cookie with the given name and value and secure
+// defaults: HttpOnly, SameSite=Lax, path "/" and Secure if the request was
+// received via HTTPS (see Scheme).
+func (c *Context) NewCookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   c.Scheme() == "https",
+	}
+}
+
+// SetCookie adds a Set-Cookie header for the given cookie to the response.
+// If not set, the path of the cookie defaults to "/" and SameSite to Lax. The
+// cookie is marked Secure if the request was received via HTTPS.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if c.Scheme() == "https" {
+		cookie.Secure = true
+	}
+	http.SetCookie(c.Response, cookie)
+}
+
+// ClearCookie adds a Set-Cookie header to the response making the client
+// delete the given cookie. The name, path and domain must match the ones the
+// cookie was set with; the value and expiry of the given cookie are ignored.
+//
+//	c.ClearCookie(&http.Cookie{Name: "flavor", Path: "/shop", Domain: "example.com"})
+func (c *Context) ClearCookie(cookie *http.Cookie) {
+	cleared := *cookie
+	cleared.Value = ""
+	cleared.Expires = time.Unix(0, 0)
+	cleared.MaxAge = -1
+	c.SetCookie(&cleared)
+}
+
+// SecureCookie returns the value of the request cookie with the given name
+// decoded with the given SecureCookie.
+func (c *Context) SecureCookie(sc *SecureCookie, name string) ([]byte, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	return sc.Decode(name, cookie.Value)
+}
+
+// SetSecureCookie sets the given cookie (see SetCookie) with the given value
+// encoded with the given SecureCookie. If the SecureCookie has a MaxAge and the
+// cookie neither has Expires nor MaxAge, MaxAge is set accordingly.
+func (c *Context) SetSecureCookie(sc *SecureCookie, cookie *http.Cookie, value []byte) error {
+	encoded, err := sc.Encode(cookie.Name, value)
+	if err != nil {
+		return err
+	}
+	cookie.Value = encoded
+	if sc.MaxAge > 0 && cookie.MaxAge == 0 && cookie.Expires.IsZero() {
+		cookie.MaxAge = int(sc.MaxAge / time.Second)
+	}
+	c.SetCookie(cookie)
+	return nil
+}
+
+var (
+	// ErrInvalidCookie is returned when decoding a cookie value which wasn't
+	// encoded (with any of the keys) of the SecureCookie or was tampered with.
+	ErrInvalidCookie = errors.New("invalid cookie value")
+	// ErrCookieExpired is returned when decoding an expired cookie value.
+	ErrCookieExpired = errors.New("cookie value expired")
+	// ErrCookieTooLong is returned when encoding a value resulting in a cookie
+	// value longer than browsers accept.
+	ErrCookieTooLong = errors.New("cookie value too long")
+)
+
+// maxCookieLength is the maximum length of encoded cookie values.
+const maxCookieLength = 4096
+
+// SecureCookie encodes and decodes cookie values signed with HMAC-SHA256 and,
+// if Encrypt is set, encrypted with AES-256-GCM. Encoded values embed their
+// expiry and are bound to the cookie name.
+//
+// Keys are given newest first: values are encoded with the first key, and
+// decoded with any of the keys. To rotate keys, prepend a new key and remove
+// the oldest key once values encoded with it have expired. The signing and
+// encryption keys are derived from the given keys, which must have at least 32
+// bytes of entropy each (e.g. generated with crypto/rand).
+type SecureCookie struct {
+	// The lifetime of encoded values, zero for values which don't expire.
+	MaxAge time.Duration
+	// If set, values are encrypted in addition to being signed.
+	Encrypt bool
+
+	keys []cookieKey
+}
+
+// cookieKey holds the keys derived from a SecureCookie key.
+type cookieKey struct {
+	hash  []byte
+	block cipher.AEAD
+}
+
+// NewSecureCookie returns a new SecureCookie using the given keys, newest first.
+func NewSecureCookie(keys ...[]byte) *SecureCookie {
+	if len(keys) == 0 {
+		panic("at least one key is required")
+	}
+	sc := &SecureCookie{keys: make([]cookieKey, len(keys))}
+	for i, key := range keys {
+		if len(key) < 32 {
+			panic("keys must be at least 32 bytes long")
+		}
+		block, err := aes.NewCipher(deriveKey(key, "encryption"))
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		sc.keys[i] = cookieKey{hash: deriveKey(key, "signing"), block: aead}
+	}
+	return sc
+}
+
+// deriveKey derives a 32 byte key for the given purpose from the given key.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("httprouter secure cookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// Encode encodes the given value for the cookie with the given name.
+func (sc *SecureCookie) Encode(name string, value []byte) (string, error) {
+	key := sc.keys[0]
+
+	// expiry (unix time, 0 if none) followed by the value
+	data := make([]byte, 8, 8+len(value))
+	if sc.MaxAge > 0 {
+		binary.BigEndian.PutUint64(data, uint64(time.Now().Add(sc.MaxAge).Unix()))
+	}
+	data = append(data, value...)
+
+	if sc.Encrypt {
+		nonce := make([]byte, key.block.NonceSize(), key.block.NonceSize()+len(data)+key.block.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		data = key.block.Seal(nonce, nonce, data, []byte(name))
+	}
+
+	data = append(data, signCookie(key.hash, name, data)...)
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	if len(name)+len(encoded) > maxCookieLength {
+		return "", ErrCookieTooLong
+	}
+	return encoded, nil
+}
+
+// Decode decodes the given value of the cookie with the given name. It returns
+// ErrInvalidCookie if the value isn't valid and ErrCookieExpired if it expired.
+func (sc *SecureCookie) Decode(name, value string) ([]byte, error) {
+	if len(name)+len(value) > maxCookieLength {
+		return nil, ErrInvalidCookie
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) < sha256.Size {
+		return nil, ErrInvalidCookie
+	}
+	data, mac := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+
+	for _, key := range sc.keys {
+		if !hmac.Equal(mac, signCookie(key.hash, name, data)) {
+			continue
+		}
+		if sc.Encrypt {
+			ns := key.block.NonceSize()
+			if len(data) < ns {
+				return nil, ErrInvalidCookie
+			}
+			if data, err = key.block.Open(nil, data[:ns], data[ns:], []byte(name)); err != nil {
+				return nil, ErrInvalidCookie
+			}
+		}
+		if len(data) < 8 {
+			return nil, ErrInvalidCookie
+		}
+		if expiry := int64(binary.BigEndian.Uint64(data)); expiry != 0 && time.Now().Unix() >= expiry {
+			return nil, ErrCookieExpired
+		}
+		return data[8:], nil
+	}
+	return nil, ErrInvalidCookie
+}
+
+// signCookie returns the HMAC-SHA256 of the cookie name and data.
+func signCookie(key []byte, name string, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,151 @@
+package httprouter
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextCookies(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.Response = c.wrapResponseWriter(w)
+	c.Request.AddCookie(&http.Cookie{Name: "flavor", Value: "chocolate"})
+
+	if cookie, err := c.Cookie("flavor"); err != nil || cookie.Value != "chocolate" {
+		t.Errorf("wrong cookie: %v, %v", cookie, err)
+	}
+	if _, err := c.Cookie("missing"); err != http.ErrNoCookie {
+		t.Errorf("expected http.ErrNoCookie, got %v", err)
+	}
+	if n := len(c.Cookies()); n != 1 {
+		t.Errorf("wrong number of cookies: want 1, got %d", n)
+	}
+
+	c.SetCookie(c.NewCookie("a", "1"))
+	c.SetCookie(&http.Cookie{Name: "b", Value: "2"})
+	c.ClearCookie(c.NewCookie("flavor", "chocolate"))
+	c.ClearCookie(&http.Cookie{Name: "c", Value: "3", Path: "/shop", Domain: "example.com"})
+	want := []string{
+		"a=1; Path=/; HttpOnly; SameSite=Lax",
+		"b=2; Path=/; SameSite=Lax",
+		"flavor=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; HttpOnly; SameSite=Lax",
+		"c=; Path=/shop; Domain=example.com; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; SameSite=Lax",
+	}
+	if got := w.Header()[HeaderSetCookie]; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong cookies:\n got %q\nwant %q", got, want)
+	}
+
+	// secure via HTTPS
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "https://example.com/", nil),
+		httptest.NewRequest(http.MethodGet, "/", nil),
+	} {
+		if req.TLS == nil {
+			req.Header.Set(HeaderXForwardedProto, "https")
+		} else {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w = httptest.NewRecorder()
+		c = &Context{Request: req}
+		c.Response = c.wrapResponseWriter(w)
+		c.SetCookie(&http.Cookie{Name: "a", Value: "1"})
+		if got := w.Header().Get(HeaderSetCookie); got != "a=1; Path=/; Secure; SameSite=Lax" {
+			t.Errorf("cookie not secure: %q", got)
+		}
+	}
+}
+
+func TestSecureCookie(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	for _, encrypt := range []bool{false, true} {
+		old := NewSecureCookie(oldKey)
+		old.Encrypt = encrypt
+		sc := NewSecureCookie(newKey, oldKey)
+		sc.Encrypt = encrypt
+
+		value := []byte("gopher")
+		encoded, err := sc.Encode("session", value)
+		if err != nil {
+			t.Fatalf("encoding failed: %v", err)
+		}
+		if strings.Contains(encoded, "=") || strings.Contains(encoded, "+") {
+			t.Errorf("encoded value %q not cookie safe", encoded)
+		}
+		if decoded, err := sc.Decode("session", encoded); err != nil || !bytes.Equal(decoded, value) {
+			t.Errorf("encrypt=%v: wrong decoding: %q, %v", encrypt, decoded, err)
+		}
+		if _, err := sc.Decode("other", encoded); err != ErrInvalidCookie {
+			t.Errorf("encrypt=%v: expected ErrInvalidCookie for other cookie name, got %v", encrypt, err)
+		}
+		if _, err := old.Decode("session", encoded); err != ErrInvalidCookie {
+			t.Errorf("encrypt=%v: expected ErrInvalidCookie for unknown key, got %v", encrypt, err)
+		}
+		tampered := []byte(encoded)
+		tampered[len(tampered)/2] ^= 1
+		if _, err := sc.Decode("session", string(tampered)); err != ErrInvalidCookie {
+			t.Errorf("encrypt=%v: expected ErrInvalidCookie for tampered value, got %v", encrypt, err)
+		}
+		if encrypt && strings.Contains(encoded, "Z29waGVy") {
+			t.Errorf("value not encrypted: %q", encoded)
+		}
+
+		// values encoded with rotated keys are still accepted
+		encoded, _ = old.Encode("session", value)
+		if decoded, err := sc.Decode("session", encoded); err != nil || !bytes.Equal(decoded, value) {
+			t.Errorf("encrypt=%v: wrong decoding with old key: %q, %v", encrypt, decoded, err)
+		}
+
+		// expiry
+		sc.MaxAge = -time.Second
+		old.MaxAge = time.Hour
+		encoded, _ = old.Encode("session", value)
+		if _, err := sc.Decode("session", encoded); err != nil {
+			t.Errorf("encrypt=%v: unexpired value rejected: %v", encrypt, err)
+		}
+		sc.MaxAge = time.Nanosecond // expiry truncated to the current second
+		encoded, _ = sc.Encode("session", value)
+		if _, err := sc.Decode("session", encoded); err != ErrCookieExpired {
+			t.Errorf("encrypt=%v: expected ErrCookieExpired, got %v", encrypt, err)
+		}
+	}
+
+	if _, err := NewSecureCookie(newKey).Encode("session", make([]byte, maxCookieLength)); err != ErrCookieTooLong {
+		t.Errorf("expected ErrCookieTooLong, got %v", err)
+	}
+	if recv := catchPanic(func() { NewSecureCookie([]byte("short")) }); recv == nil {
+		t.Error("no panic for short key")
+	}
+}
+
+func TestContextSecureCookie(t *testing.T) {
+	sc := NewSecureCookie(bytes.Repeat([]byte{1}, 32))
+	sc.MaxAge = time.Hour
+
+	w := httptest.NewRecorder()
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.Response = c.wrapResponseWriter(w)
+	if err := c.SetSecureCookie(sc, c.NewCookie("session", ""), []byte("gopher")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != 3600 {
+		t.Fatalf("wrong cookies: %v", cookies)
+	}
+
+	c = &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.Request.AddCookie(cookies[0])
+	if value, err := c.SecureCookie(sc, "session"); err != nil || string(value) != "gopher" {
+		t.Errorf("wrong value: %q, %v", value, err)
+	}
+	if _, err := c.SecureCookie(sc, "missing"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("expected http.ErrNoCookie, got %v", err)
+	}
+}
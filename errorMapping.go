@@ -0,0 +1,68 @@
+package httprouter
+
+import (
+	"errors"
+	"reflect"
+)
+
+// errorMapping maps errors to the problem describing them.
+type errorMapping struct {
+	target  error        // matched via errors.Is, if set
+	typ     reflect.Type // matched via errors.As otherwise
+	problem *Problem
+}
+
+// MapError registers the given problem for all errors matching the given
+// target error via errors.Is (e.g. a sentinel error like ErrNotFound).
+// Errors passed to Context.Error with status code 0 and errors returned by a
+// HandleE are answered with the status code of the problem. Type, title,
+// detail and extension members are taken from the problem. If the problem has
+// no detail, the message of the matched error (not of the errors wrapping it)
+// is rendered instead, except for server errors (5xx), whose messages are
+// logged but never sent to the client.
+// Mappings are consulted in the order of registration.
+//
+//	router.MapError(ErrNotFound, httprouter.NewProblem(http.StatusNotFound, ""))
+func (r *Router) MapError(target error, p *Problem) {
+	if target == nil {
+		panic("target must not be nil")
+	}
+	r.errorMappings = append(r.errorMappings, errorMapping{target: target, problem: p})
+}
+
+// MapErrorType registers the given problem for all errors matching the type
+// the given target points to via errors.As (e.g. new(*ValidationError)).
+// See MapError for details.
+//
+//	router.MapErrorType(new(*ValidationError), httprouter.NewProblem(http.StatusUnprocessableEntity, ""))
+func (r *Router) MapErrorType(target interface{}, p *Problem) {
+	typ := reflect.TypeOf(target)
+	if typ == nil || typ.Kind() != reflect.Ptr || reflect.ValueOf(target).IsNil() {
+		panic("target must be a non-nil pointer")
+	}
+	if e := typ.Elem(); e.Kind() != reflect.Interface && !e.Implements(errorType) {
+		panic("target must point to an interface or a type implementing error")
+	}
+	r.errorMappings = append(r.errorMappings, errorMapping{typ: typ.Elem(), problem: p})
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// mapError returns the problem registered for the given error and the error
+// in its chain it was matched with, or nil if the error isn't mapped.
+func (r *Router) mapError(err error) (*Problem, error) {
+	if err == nil {
+		return nil, nil
+	}
+	for _, m := range r.errorMappings {
+		if m.target != nil {
+			if errors.Is(err, m.target) {
+				return m.problem, m.target
+			}
+		} else if target := reflect.New(m.typ); errors.As(err, target.Interface()) {
+			matched, _ := target.Elem().Interface().(error)
+			return m.problem, matched
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,110 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errNotFound    = errors.New("item not found")
+	errQuota       = errors.New("quota of tenant 7 exceeded")
+	errUnavailable = errors.New("backend 10.0.0.2 unavailable")
+)
+
+type validationError struct {
+	Field string
+}
+
+func (e *validationError) Error() string {
+	return "invalid " + e.Field
+}
+
+func TestRouterMapError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	router := New()
+	router.Logger = &logger
+	router.MapError(errNotFound, NewProblem(http.StatusNotFound, ""))
+	router.MapErrorType(new(*validationError), NewTypedProblem("https://example.com/validation", "Invalid input", http.StatusUnprocessableEntity, ""))
+	router.MapError(errQuota, NewTypedProblem("https://example.com/quota", "Quota exceeded", http.StatusTooManyRequests, "Try again later.").With("retry_after", 60))
+	router.MapError(errUnavailable, NewProblem(http.StatusServiceUnavailable, ""))
+	router.GET("/sentinel", HandleE(func(_ *Context) error {
+		return fmt.Errorf("loading: %w", errNotFound)
+	}).Handle())
+	router.GET("/typed", HandleE(func(_ *Context) error {
+		return &validationError{Field: "name"}
+	}).Handle())
+	router.GET("/explicit", func(c *Context) {
+		c.Error(http.StatusGone, errNotFound)
+	})
+	router.GET("/quota", HandleE(func(_ *Context) error {
+		return fmt.Errorf("uploading: %w", errQuota)
+	}).Handle())
+	router.GET("/unavailable", HandleE(func(_ *Context) error {
+		return fmt.Errorf("loading: %w", errUnavailable)
+	}).Handle())
+	router.GET("/unmapped", HandleE(func(_ *Context) error {
+		return errors.New("connection to 10.0.0.1 refused")
+	}).Handle())
+
+	testRoutes := []struct {
+		route   string
+		code    int
+		body    string
+		problem string
+	}{
+		{"/sentinel", http.StatusNotFound, `{"error":"item not found"}`,
+			`{"detail":"item not found","instance":"/sentinel","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"/typed", http.StatusUnprocessableEntity, `{"error":"invalid name"}`,
+			`{"detail":"invalid name","instance":"/typed","status":422,"title":"Invalid input","type":"https://example.com/validation"}`},
+		{"/explicit", http.StatusGone, `{"error":"item not found"}`,
+			`{"detail":"item not found","instance":"/explicit","status":410,"title":"Gone","type":"about:blank"}`},
+		{"/quota", http.StatusTooManyRequests, `{"error":"Try again later."}`,
+			`{"detail":"Try again later.","instance":"/quota","retry_after":60,"status":429,"title":"Quota exceeded","type":"https://example.com/quota"}`},
+		{"/unavailable", http.StatusServiceUnavailable, `{"error":"Service Unavailable"}`,
+			`{"instance":"/unavailable","status":503,"title":"Service Unavailable","type":"about:blank"}`},
+		{"/unmapped", http.StatusInternalServerError, `{"error":"Internal Server Error"}`,
+			`{"instance":"/unmapped","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+	}
+	for _, problem := range []bool{false, true} {
+		router.ProblemDetails = problem
+		for _, tr := range testRoutes {
+			want := tr.body
+			if problem {
+				want = tr.problem
+			}
+			r, _ := http.NewRequest(http.MethodGet, tr.route, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tr.code || w.Body.String() != want {
+				t.Errorf("route %s: want %d %s, got %d %s", tr.route, tr.code, want, w.Code, w.Body.String())
+			}
+		}
+	}
+
+	// server errors are logged
+	for _, msg := range []string{"loading: backend 10.0.0.2 unavailable", "connection to 10.0.0.1 refused"} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("server error %q not logged: %s", msg, buf.String())
+		}
+	}
+
+	if recv := catchPanic(func() {
+		router.MapErrorType(validationError{}, NewProblem(http.StatusBadRequest, ""))
+	}); recv == nil {
+		t.Error("mapping a non-pointer target did not panic")
+	}
+	if recv := catchPanic(func() {
+		router.MapErrorType(new(string), NewProblem(http.StatusBadRequest, ""))
+	}); recv == nil {
+		t.Error("mapping a pointer to a non-error type did not panic")
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/heimdalr/httprouter
 
-go 1.7
+go 1.13
 
 require github.com/rs/zerolog v1.20.0
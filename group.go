@@ -0,0 +1,117 @@
+package httprouter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group is a set of routes sharing a common path prefix. Routes registered
+// with a group are registered with the router under the group's prefix.
+type Group struct {
+	router *Router
+	prefix string
+}
+
+// Group returns a new group of routes with the given path prefix.
+// The prefix must begin with '/' and may contain named parameters.
+func (r *Router) Group(prefix string) *Group {
+	if len(prefix) < 1 || prefix[0] != '/' {
+		panic("prefix must begin with '/' in prefix '" + prefix + "'")
+	}
+	return &Group{
+		router: r,
+		prefix: strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Group returns a new group of routes nested in this group, i.e. with the
+// given path prefix appended to the prefix of this group.
+func (g *Group) Group(prefix string) *Group {
+	return g.router.Group(g.prefix + prefix)
+}
+
+// SetTrailingSlash sets the trailing slash policy for all paths beginning with
+// the prefix of the group, overriding Router.TrailingSlash.
+func (g *Group) SetTrailingSlash(policy TrailingSlashPolicy) {
+	if g.router.trailingSlash == nil {
+		g.router.trailingSlash = make(map[string]TrailingSlashPolicy)
+	}
+	g.router.trailingSlash[g.prefix] = policy
+}
+
+// GET is a shortcut for group.Handle(http.MethodGet, path, handle)
+func (g *Group) GET(path string, handle Handle) *Route {
+	return g.Handle(http.MethodGet, path, handle)
+}
+
+// HEAD is a shortcut for group.Handle(http.MethodHead, path, handle)
+func (g *Group) HEAD(path string, handle Handle) *Route {
+	return g.Handle(http.MethodHead, path, handle)
+}
+
+// OPTIONS is a shortcut for group.Handle(http.MethodOptions, path, handle)
+func (g *Group) OPTIONS(path string, handle Handle) *Route {
+	return g.Handle(http.MethodOptions, path, handle)
+}
+
+// POST is a shortcut for group.Handle(http.MethodPost, path, handle)
+func (g *Group) POST(path string, handle Handle) *Route {
+	return g.Handle(http.MethodPost, path, handle)
+}
+
+// PUT is a shortcut for group.Handle(http.MethodPut, path, handle)
+func (g *Group) PUT(path string, handle Handle) *Route {
+	return g.Handle(http.MethodPut, path, handle)
+}
+
+// PATCH is a shortcut for group.Handle(http.MethodPatch, path, handle)
+func (g *Group) PATCH(path string, handle Handle) *Route {
+	return g.Handle(http.MethodPatch, path, handle)
+}
+
+// DELETE is a shortcut for group.Handle(http.MethodDelete, path, handle)
+func (g *Group) DELETE(path string, handle Handle) *Route {
+	return g.Handle(http.MethodDelete, path, handle)
+}
+
+// Handle registers a new request handle with the given method and the given
+// path appended to the prefix of the group (see Router.Handle).
+func (g *Group) Handle(method, path string, handle Handle) *Route {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	return g.router.Handle(method, g.prefix+path, handle)
+}
+
+// matchPrefix reports whether the given path begins with the given route
+// prefix. Named parameters in the prefix match any non-empty path segment,
+// a catch-all parameter matches the remainder of the path.
+func matchPrefix(prefix, path string) bool {
+	for len(prefix) > 0 {
+		switch prefix[0] {
+		case ':':
+			end := strings.IndexByte(path, '/')
+			if end < 0 {
+				end = len(path)
+			}
+			if end == 0 {
+				return false
+			}
+			path = path[end:]
+			if end = strings.IndexByte(prefix, '/'); end < 0 {
+				end = len(prefix)
+			}
+			prefix = prefix[end:]
+		case '*':
+			return true
+		default:
+			if len(path) == 0 || path[0] != prefix[0] {
+				return false
+			}
+			prefix, path = prefix[1:], path[1:]
+		}
+	}
+
+	// the prefix must end at a segment boundary of the path
+	return len(path) == 0 || path[0] == '/'
+}
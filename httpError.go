@@ -2,17 +2,148 @@ package httprouter
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
 )
 
 // HTTPError represents an error that occurred while handling a request.
 type HTTPError struct {
-	Code  int   `json:"code"`
-	Error error `json:"error"`
+	Code int   `json:"code"`
+	Err  error `json:"error"`
+	// Details are structured details about the error, rendered as "details"
+	// member (e.g. a list of invalid fields).
+	Details interface{} `json:"details,omitempty"`
+}
+
+// NewHTTPError returns a new HTTPError with the given status code and error.
+// The error may be nil, in which case the status text is used as message.
+func NewHTTPError(code int, err error) *HTTPError {
+	return &HTTPError{Code: code, Err: err}
+}
+
+// Error returns the message of the wrapped error or, if there is none, the
+// status text of the status code.
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error (if any).
+func (e *HTTPError) Unwrap() error {
+	return e.Err
 }
 
 func (e HTTPError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Error string `json:"error"`
-	}{Error: e.Error.Error()})
+		Code    int         `json:"code"`
+		Error   string      `json:"error"`
+		Details interface{} `json:"details,omitempty"`
+	}{Code: e.Code, Error: e.Error(), Details: e.Details})
+}
+
+// errorStatus returns the status code for the given error, i.e. the code of the
+// HTTPError or the status of the Problem if the error is (or wraps) one, 0
+// otherwise.
+func errorStatus(err error) int {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	var p *Problem
+	if errors.As(err, &p) {
+		return p.Status
+	}
+	return 0
 }
 
+// DefaultErrorHandler renders the given error as JSON object with the error
+// message as "error" member, or as problem details if Router.ProblemDetails is
+// set. Errors mapped to a problem (see Router.MapError) are rendered with its
+// type, title, detail and extension members, where the detail defaults to the
+// message of the matched error. For server errors (5xx) the status text (or
+// the detail of the mapped problem) is sent instead of the message, which
+// might reveal internals, and the error is logged. Details of an HTTPError are
+// rendered as "details" member, a Problem is rendered as is.
+// If the response was already written, the error is only logged.
+func DefaultErrorHandler(status int, err error, c *Context) {
+	if c.Response.Written() {
+		c.Logger.Error().Err(err).Int("status", status).Msg("error after response was written")
+		return
+	}
+
+	// a problem is rendered as is
+	var p *Problem
+	if errors.As(err, &p) {
+		if p.Status != status {
+			cp := *p
+			cp.Status = status
+			p = &cp
+		}
+		c.Response.Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+		c.Response.WriteHeaderError(status, err)
+		bytes, _ := json.Marshal(p)
+		_, _ = c.Response.Write(bytes)
+		return
+	}
+
+	// look up the problem registered for the error (if any)
+	var mapped *Problem
+	matched := err
+	if c.router != nil {
+		if mapped, matched = c.router.mapError(err); matched == nil {
+			matched = err
+		}
+	}
+
+	msg := http.StatusText(status)
+	switch {
+	case status >= http.StatusInternalServerError:
+		c.Logger.Error().Err(err).Int("status", status).Msg("")
+		if mapped != nil && mapped.Detail != "" {
+			msg = mapped.Detail
+		}
+	case mapped != nil && mapped.Detail != "":
+		msg = mapped.Detail
+	case matched != nil:
+		msg = matched.Error()
+	}
+
+	var details interface{}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		details = he.Details
+	}
+
+	var bytes []byte
+	if c.router != nil && c.router.ProblemDetails {
+		p = NewProblem(status, "")
+		if mapped != nil && mapped.Type != "" && mapped.Type != p.Type {
+			// for about:blank, the title must be the status text
+			p.Type, p.Title = mapped.Type, mapped.Title
+		}
+		if msg != http.StatusText(status) {
+			p.Detail = msg
+		}
+		p.Instance = c.Request.URL.Path
+		if mapped != nil {
+			for key, value := range mapped.Extensions {
+				p.With(key, value)
+			}
+		}
+		if details != nil {
+			p.With("details", details)
+		}
+		bytes, _ = json.Marshal(p)
+		c.Response.Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+	} else {
+		bytes, _ = json.Marshal(struct {
+			Error   string      `json:"error"`
+			Details interface{} `json:"details,omitempty"`
+		}{Error: msg, Details: details})
+		c.Response.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	}
+	c.Response.WriteHeaderError(status, err)
+	_, _ = c.Response.Write(bytes)
+}
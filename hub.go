@@ -0,0 +1,269 @@
+package httprouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SlowConsumerPolicy determines what a Hub does with subscribers whose buffer
+// is full when a message is published.
+type SlowConsumerPolicy int
+
+const (
+	// DropMessages drops the message for slow subscribers, see
+	// Subscription.Dropped.
+	DropMessages SlowConsumerPolicy = iota
+	// Disconnect unsubscribes slow subscribers, their subscription ends with
+	// ErrSlowConsumer.
+	Disconnect
+)
+
+// ErrSlowConsumer is the error of subscriptions ended by the Disconnect policy.
+var ErrSlowConsumer = errors.New("subscriber too slow")
+
+// errUnsubscribed is the error of subscriptions closed by the subscriber.
+var errUnsubscribed = errors.New("unsubscribed")
+
+// defaultHubBufferSize is the default number of messages buffered per
+// subscriber, see Hub.BufferSize.
+const defaultHubBufferSize = 16
+
+// Message is a message published to the subscribers of a topic of a Hub.
+type Message struct {
+	// The event name, e.g. the event of server-sent events.
+	Event string
+	// The ID of the message, e.g. the ID of server-sent events.
+	ID string
+	// The payload.
+	Data []byte
+}
+
+// Hub fans out messages published to a topic to all subscribers of the topic,
+// e.g. the clients of server-sent events (see ServeSSE) or WebSocket
+// connections. Every subscriber has a bounded buffer, publishing never blocks:
+// if the buffer of a subscriber is full, the Policy applies.
+//
+//	hub := NewHub()
+//	router.GET("/rooms/:room/events", hub.ServeSSE("room"))
+//	router.POST("/rooms/:room/messages", HandleE(func(c *Context) error {
+//		...
+//		hub.PublishJSON(c.Params.ByName("room"), "message", "", msg)
+//		return c.NoContent(http.StatusAccepted)
+//	}).Handle())
+type Hub struct {
+	// The number of messages buffered per subscriber.
+	// Default: 16
+	BufferSize int
+	// The policy for subscribers whose buffer is full.
+	// Default: DropMessages
+	Policy SlowConsumerPolicy
+
+	lock   sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// NewHub returns a new hub.
+func NewHub() *Hub {
+	return &Hub{
+		BufferSize: defaultHubBufferSize,
+		Policy:     DropMessages,
+	}
+}
+
+// Subscription is the subscription of a topic of a Hub.
+type Subscription struct {
+	// accessed atomically, first for 64-bit alignment on 32-bit platforms
+	dropped uint64
+
+	hub   *Hub
+	topic string
+	c     chan Message
+	done  chan struct{}
+	err   error // guarded by the lock of the hub
+}
+
+// SubscribeTopic subscribes to the given topic until the subscription is
+// closed or the given context is cancelled.
+func (h *Hub) SubscribeTopic(ctx context.Context, topic string) *Subscription {
+	size := h.BufferSize
+	if size <= 0 {
+		size = defaultHubBufferSize
+	}
+	s := &Subscription{
+		hub:   h,
+		topic: topic,
+		c:     make(chan Message, size),
+		done:  make(chan struct{}),
+	}
+
+	h.lock.Lock()
+	if h.topics == nil {
+		h.topics = make(map[string]map[*Subscription]struct{})
+	}
+	subs := h.topics[topic]
+	if subs == nil {
+		subs = make(map[*Subscription]struct{})
+		h.topics[topic] = subs
+	}
+	subs[s] = struct{}{}
+	h.lock.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.unsubscribe(s, ctx.Err())
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+// Subscribe subscribes to the topic given by the values of the given route
+// parameters of the request (joined by "/"), or by the values of all
+// parameters if none are given. E.g. requests of the route "/events/:room" for
+// the path "/events/lobby" subscribe to the topic "lobby".
+// The subscription is closed when the request is cancelled or the handler
+// returns.
+func (h *Hub) Subscribe(c *Context, params ...string) *Subscription {
+	s := h.SubscribeTopic(c.Request.Context(), topicOf(c.Params, params))
+	c.onRelease(s.Close)
+	return s
+}
+
+// topicOf returns the topic given by the values of the given parameters, or by
+// the values of all parameters if none are given.
+func topicOf(ps Params, params []string) string {
+	var values []string
+	if len(params) == 0 {
+		for _, p := range ps {
+			if p.Key != MatchedRoutePathParam {
+				values = append(values, p.Value)
+			}
+		}
+	} else {
+		for _, name := range params {
+			values = append(values, ps.ByName(name))
+		}
+	}
+	return strings.Join(values, "/")
+}
+
+// Publish publishes the given message to all subscribers of the given topic
+// and returns the number of subscribers it was delivered to.
+func (h *Hub) Publish(topic string, m Message) int {
+	delivered := 0
+	var slow []*Subscription
+
+	h.lock.RLock()
+	for s := range h.topics[topic] {
+		select {
+		case s.c <- m:
+			delivered++
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+			if h.Policy == Disconnect {
+				slow = append(slow, s)
+			}
+		}
+	}
+	h.lock.RUnlock()
+
+	for _, s := range slow {
+		h.unsubscribe(s, ErrSlowConsumer)
+	}
+	return delivered
+}
+
+// PublishJSON publishes a message with the given event name, ID and the JSON
+// encoding of v as payload, see Publish.
+func (h *Hub) PublishJSON(topic, event, id string, v interface{}) (int, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return h.Publish(topic, Message{Event: event, ID: id, Data: b}), nil
+}
+
+// Subscribers returns the number of subscribers of the given topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.topics[topic])
+}
+
+// unsubscribe removes the given subscription from the hub and closes its
+// channel, unless that happened before.
+func (h *Hub) unsubscribe(s *Subscription, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	subs := h.topics[s.topic]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.topics, s.topic)
+	}
+	s.err = err
+	// publishers send while holding the read lock, hence no message is sent
+	// on the closed channel
+	close(s.c)
+	close(s.done)
+}
+
+// Topic returns the subscribed topic.
+func (s *Subscription) Topic() string {
+	return s.topic
+}
+
+// C returns the channel delivering the published messages. The channel is
+// closed when the subscription ends, see Err.
+func (s *Subscription) C() <-chan Message {
+	return s.c
+}
+
+// Close ends the subscription. Buffered messages can still be received from C.
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s, errUnsubscribed)
+}
+
+// Err returns why the subscription ended: ErrSlowConsumer if the subscriber
+// was disconnected, the error of the context if it was cancelled, nil if the
+// subscription is active or was closed.
+func (s *Subscription) Err() error {
+	s.hub.lock.RLock()
+	defer s.hub.lock.RUnlock()
+	if s.err == errUnsubscribed {
+		return nil
+	}
+	return s.err
+}
+
+// Dropped returns the number of messages dropped because the buffer of the
+// subscriber was full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// ServeSSE returns a handle streaming the messages published to the topic of
+// the given route parameters (see Subscribe) as server-sent events (see
+// Context.SSE), until the client goes away or is disconnected as slow
+// consumer.
+func (h *Hub) ServeSSE(params ...string) Handle {
+	return func(c *Context) {
+		s := h.Subscribe(c, params...)
+		stream := c.SSE()
+		for m := range s.C() {
+			if stream.Send(m.Event, m.ID, string(m.Data)) != nil {
+				return
+			}
+		}
+		if s.Err() == ErrSlowConsumer {
+			c.Logger.Warn().Str("topic", s.Topic()).Msg("disconnected slow event stream subscriber")
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package httprouter
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	hub.BufferSize = 2
+	a := hub.SubscribeTopic(context.Background(), "lobby")
+	b := hub.SubscribeTopic(context.Background(), "lobby")
+	other := hub.SubscribeTopic(context.Background(), "other")
+
+	if n := hub.Subscribers("lobby"); n != 2 {
+		t.Errorf("wrong number of subscribers: %d", n)
+	}
+	if n := hub.Publish("lobby", Message{Data: []byte("1")}); n != 2 {
+		t.Errorf("delivered to %d subscribers", n)
+	}
+	if n, err := hub.PublishJSON("lobby", "json", "2", map[string]int{"n": 2}); n != 2 || err != nil {
+		t.Errorf("delivered to %d subscribers: %v", n, err)
+	}
+	if m := <-a.C(); string(m.Data) != "1" {
+		t.Errorf("wrong message: %+v", m)
+	}
+	if m := <-b.C(); string(m.Data) != "1" {
+		t.Errorf("wrong message: %+v", m)
+	}
+	if m := <-a.C(); m.Event != "json" || m.ID != "2" || string(m.Data) != `{"n":2}` {
+		t.Errorf("wrong message: %+v", m)
+	}
+	if len(other.C()) != 0 {
+		t.Error("message delivered to other topic")
+	}
+
+	// b's buffer is full, the message is dropped for b only
+	hub.Publish("lobby", Message{Data: []byte("3")})
+	if n := hub.Publish("lobby", Message{Data: []byte("4")}); n != 1 {
+		t.Errorf("delivered to %d subscribers", n)
+	}
+	if a.Dropped() != 0 || b.Dropped() != 1 || b.Err() != nil {
+		t.Errorf("wrong drops: %d, %d, %v", a.Dropped(), b.Dropped(), b.Err())
+	}
+
+	// buffered messages can be received after closing
+	b.Close()
+	var got []string
+	for m := range b.C() {
+		got = append(got, string(m.Data))
+	}
+	if strings.Join(got, ",") != `{"n":2},3` || b.Err() != nil {
+		t.Errorf("wrong messages after close: %v, %v", got, b.Err())
+	}
+	if n := hub.Subscribers("lobby"); n != 1 {
+		t.Errorf("wrong number of subscribers: %d", n)
+	}
+	a.Close()
+	a.Close()
+	if n := hub.Subscribers("lobby"); n != 0 {
+		t.Errorf("wrong number of subscribers: %d", n)
+	}
+}
+
+func TestHubDisconnect(t *testing.T) {
+	hub := NewHub()
+	hub.BufferSize = 1
+	hub.Policy = Disconnect
+	slow := hub.SubscribeTopic(context.Background(), "t")
+
+	hub.Publish("t", Message{})
+	hub.Publish("t", Message{})
+	if slow.Err() != ErrSlowConsumer || hub.Subscribers("t") != 0 {
+		t.Errorf("slow consumer not disconnected: %v", slow.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := hub.SubscribeTopic(ctx, "t")
+	cancel()
+	select {
+	case <-cancelled.done:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not ended by cancellation")
+	}
+	if cancelled.Err() != context.Canceled || hub.Subscribers("t") != 0 {
+		t.Errorf("wrong error: %v", cancelled.Err())
+	}
+}
+
+func TestHubServeSSE(t *testing.T) {
+	hub := NewHub()
+	router := New()
+	router.SSEKeepAlive = -1
+	router.GET("/rooms/:room/events", hub.ServeSSE("room"))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/rooms/lobby/events", nil)
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// wait for the subscription
+	for i := 0; hub.Subscribers("lobby") == 0; i++ {
+		if i == 100 {
+			t.Fatal("no subscriber")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hub.Publish("lobby", Message{Event: "chat", ID: "1", Data: []byte("hi")})
+	lines := bufio.NewReader(res.Body)
+	for _, want := range []string{"event: chat\n", "id: 1\n", "data: hi\n"} {
+		if line, _ := lines.ReadString('\n'); line != want {
+			t.Errorf("wrong line: %q, want %q", line, want)
+		}
+	}
+
+	// the subscription ends with the request
+	cancel()
+	for i := 0; hub.Subscribers("lobby") != 0; i++ {
+		if i == 100 {
+			t.Fatal("subscriber not removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHubSubscribeTopic(t *testing.T) {
+	ps := Params{{"org", "acme"}, {MatchedRoutePathParam, "/x"}, {"room", "lobby"}}
+	if topic := topicOf(ps, nil); topic != "acme/lobby" {
+		t.Errorf("wrong topic: %s", topic)
+	}
+	if topic := topicOf(ps, []string{"room"}); topic != "lobby" {
+		t.Errorf("wrong topic: %s", topic)
+	}
+}
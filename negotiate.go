@@ -0,0 +1,153 @@
+package httprouter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultOffers are the content types offered by Negotiate if none are given.
+var defaultOffers = []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+
+// Negotiate renders the given value with the given status code in the content
+// type most acceptable to the client according to the Accept header of the
+// request (see NegotiateFormat). The offered content types are given in order
+// of preference, supported are text/plain (rendering the value formatted with
+// fmt.Sprint) and all content types with a codec (see Router.RegisterCodec).
+// If no offers are given, JSON, XML and plain text are offered.
+// The response varies by the Accept header, which is indicated with the Vary
+// header. If none of the offers is acceptable, an error with status code 406 is
+// rendered with Context.Error and nil is returned.
+func (c *Context) Negotiate(code int, data interface{}, offers ...string) error {
+	if len(offers) == 0 {
+		offers = defaultOffers
+	}
+	addVary(c.Response.Header(), HeaderAccept)
+
+	offer := c.NegotiateFormat(offers...)
+	switch strings.ToLower(mediaType(offer)) {
+	case MIMETextPlain:
+		return c.String(code, fmt.Sprint(data))
+	case "":
+		c.Error(http.StatusNotAcceptable, NewHTTPError(http.StatusNotAcceptable,
+			fmt.Errorf("none of the content types %s is acceptable", strings.Join(offers, ", "))))
+		return nil
+	default:
+		return c.Encode(code, offer, data)
+	}
+}
+
+// NegotiateFormat returns the offered content type most acceptable to the
+// client according to the Accept header of the request as defined by
+// RFC 9110, section 12.5.1: every offer gets the quality value of the most
+// specific media range matching it (type/subtype before type/* before */*),
+// the offer with the highest quality value wins, ties are broken by the order
+// of the offers. If the request has no Accept header, the first offer is
+// returned. If none of the offers is acceptable, "" is returned.
+func (c *Context) NegotiateFormat(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	accept := c.Request.Header[HeaderAccept]
+	if len(accept) == 0 {
+		return offers[0]
+	}
+	ranges := parseAccept(strings.Join(accept, ","))
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ := strings.ToLower(mediaType(offer))
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.match(typ); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptRange is a media range of an Accept header with its quality value.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// match reports how specifically the range matches the given media type: 2
+// for an exact match, 1 for type/*, 0 for */* and -1 if it doesn't match.
+func (r acceptRange) match(mediaType string) int {
+	i := strings.IndexByte(mediaType, '/')
+	if i < 0 {
+		return -1
+	}
+	typ, subtype := mediaType[:i], mediaType[i+1:]
+	switch {
+	case r.typ == "*" && r.subtype == "*":
+		return 0
+	case r.typ == typ && r.subtype == "*":
+		return 1
+	case r.typ == typ && r.subtype == subtype:
+		return 2
+	}
+	return -1
+}
+
+// parseAccept parses the media ranges of an Accept header. Invalid ranges are
+// skipped.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		typ := strings.ToLower(strings.TrimSpace(params[0]))
+		i := strings.IndexByte(typ, '/')
+		if i <= 0 || i == len(typ)-1 {
+			continue
+		}
+		r := acceptRange{typ: typ[:i], subtype: typ[i+1:], q: 1}
+		if r.typ == "*" && r.subtype != "*" {
+			continue
+		}
+		valid := true
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(kv[0], "q") {
+				continue
+			}
+			q, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			r.q = q
+		}
+		if valid {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// mediaType returns the given content type without parameters.
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.TrimSpace(contentType)
+}
+
+// addVary adds the given header name to the Vary header unless it is listed
+// already.
+func addVary(h http.Header, name string) {
+	for _, v := range h[HeaderVary] {
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	h.Add(HeaderVary, name)
+}
@@ -0,0 +1,77 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextNegotiateFormat(t *testing.T) {
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", MIMEApplicationJSON},
+		{"*/*", MIMEApplicationJSON},
+		{"application/xml", MIMEApplicationXML},
+		{"TEXT/PLAIN", MIMETextPlain},
+		{"text/*", MIMETextPlain},
+		{"application/xml;q=0.9, text/plain", MIMETextPlain},
+		{"application/json;q=0.5, application/xml;q=0.5", MIMEApplicationJSON},
+		{"*/*;q=0.1, application/xml;q=0.2", MIMEApplicationXML},
+		{"*/*, application/json;q=0", MIMEApplicationXML},
+		{"application/*;q=0.3, application/json;q=0.2, text/plain;q=0.1", MIMEApplicationXML},
+		{"text/html;level=1;q=0.5, text/plain;q=0.4", MIMETextPlain},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+		{"application/json;q=x, text/plain;q=0.1", MIMETextPlain},
+		{"*/json, text/plain", MIMETextPlain},
+	}
+	for _, test := range tests {
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		if test.accept != "" {
+			c.Request.Header.Set(HeaderAccept, test.accept)
+		}
+		if got := c.NegotiateFormat(offers...); got != test.want {
+			t.Errorf("%q: want %q, got %q", test.accept, test.want, got)
+		}
+	}
+}
+
+type greeting struct {
+	Text string `json:"text" xml:"text"`
+}
+
+func TestContextNegotiate(t *testing.T) {
+	router := New()
+	router.GET("/greeting", HandleE(func(c *Context) error {
+		return c.Negotiate(http.StatusOK, greeting{"hello"})
+	}).Handle())
+
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"application/json", http.StatusOK, MIMEApplicationJSONCharsetUTF8},
+		{"application/xml", http.StatusOK, MIMEApplicationXMLCharsetUTF8},
+		{"text/plain", http.StatusOK, MIMETextPlainCharsetUTF8},
+		{"image/png", http.StatusNotAcceptable, MIMEApplicationJSONCharsetUTF8},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/greeting", nil)
+		req.Header.Set(HeaderAccept, test.accept)
+		router.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%q: wrong status code: want %d, got %d", test.accept, test.status, w.Code)
+		}
+		if got := w.Header().Get(HeaderContentType); got != test.contentType {
+			t.Errorf("%q: wrong content type: want %q, got %q", test.accept, test.contentType, got)
+		}
+		if got := w.Header().Get(HeaderVary); got != HeaderAccept {
+			t.Errorf("%q: wrong Vary header: want %q, got %q", test.accept, HeaderAccept, got)
+		}
+	}
+}
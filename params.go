@@ -0,0 +1,160 @@
+package httprouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrMissingParam is wrapped by the errors returned by the typed Params getters
+// if the route has no parameter with the given name.
+var ErrMissingParam = errors.New("missing path parameter")
+
+// value returns the value of the parameter with the given name or an error
+// wrapping ErrMissingParam.
+func (ps Params) value(name string) (string, error) {
+	v, ok := ps.Get(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrMissingParam, name)
+	}
+	return v, nil
+}
+
+// paramError returns an HTTPError with status code 400 for the parameter with
+// the given name.
+func paramError(name string, err error) error {
+	fields := []FieldError{{Field: name, Source: "param", Name: name, Err: err}}
+	return &HTTPError{Code: http.StatusBadRequest, Err: &BindError{Fields: fields}, Details: fields}
+}
+
+// Int returns the value of the parameter with the given name as int. If the
+// value isn't an integer, an HTTPError with status code 400 is returned.
+func (ps Params) Int(name string) (int, error) {
+	v, err := ps.value(name)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, paramError(name, errors.New("invalid integer"))
+	}
+	return i, nil
+}
+
+// Int64 returns the value of the parameter with the given name as int64. If the
+// value isn't an integer, an HTTPError with status code 400 is returned.
+func (ps Params) Int64(name string) (int64, error) {
+	v, err := ps.value(name)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, paramError(name, errors.New("invalid integer"))
+	}
+	return i, nil
+}
+
+// Uint returns the value of the parameter with the given name as uint. If the
+// value isn't an unsigned integer, an HTTPError with status code 400 is
+// returned.
+func (ps Params) Uint(name string) (uint, error) {
+	v, err := ps.value(name)
+	if err != nil {
+		return 0, err
+	}
+	u, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, paramError(name, errors.New("invalid unsigned integer"))
+	}
+	return uint(u), nil
+}
+
+// Uint64 returns the value of the parameter with the given name as uint64. If
+// the value isn't an unsigned integer, an HTTPError with status code 400 is
+// returned.
+func (ps Params) Uint64(name string) (uint64, error) {
+	v, err := ps.value(name)
+	if err != nil {
+		return 0, err
+	}
+	u, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, paramError(name, errors.New("invalid unsigned integer"))
+	}
+	return u, nil
+}
+
+// UUID returns the value of the parameter with the given name as UUID in its
+// canonical, lower case form (e.g. "123e4567-e89b-12d3-a456-426614174000").
+// If the value isn't a UUID, an HTTPError with status code 400 is returned.
+func (ps Params) UUID(name string) (string, error) {
+	v, err := ps.value(name)
+	if err != nil {
+		return "", err
+	}
+	if !isUUID(v) {
+		return "", paramError(name, errors.New("invalid UUID"))
+	}
+	return strings.ToLower(v), nil
+}
+
+// isUUID reports whether s is a UUID in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Decode fills the fields of the struct dst points to which are tagged with
+// `param:"name"` with the values of the parameters, converted to the type of
+// the field (see Context.Bind):
+//
+//	// route /orgs/:org/repos/:id
+//	var p struct {
+//		Org string `param:"org"`
+//		ID  uint64 `param:"id"`
+//	}
+//	if err := c.Params.Decode(&p); err != nil {
+//		return err
+//	}
+//
+// If values can't be converted, an HTTPError with status code 400 wrapping a
+// BindError is returned.
+func (ps Params) Decode(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("dst must be a non-nil pointer to a struct")
+	}
+	var fields []FieldError
+	bindValues(v.Elem(), "", ps.bindSource(), &fields)
+	if len(fields) > 0 {
+		return &HTTPError{Code: http.StatusBadRequest, Err: &BindError{Fields: fields}, Details: fields}
+	}
+	return nil
+}
+
+// bindSource returns the params as source of values for binding.
+func (ps Params) bindSource() bindSource {
+	return bindSource{tag: "param", values: func(name string) ([]string, bool) {
+		if v, ok := ps.Get(name); ok {
+			return []string{v}, true
+		}
+		return nil, false
+	}}
+}
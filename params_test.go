@@ -0,0 +1,99 @@
+package httprouter
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParamsGet(t *testing.T) {
+	ps := Params{Param{"empty", ""}, Param{"name", "gopher"}}
+	if v, ok := ps.Get("name"); v != "gopher" || !ok {
+		t.Errorf("wrong value: %q, %v", v, ok)
+	}
+	if v, ok := ps.Get("empty"); v != "" || !ok {
+		t.Errorf("empty param not found: %q, %v", v, ok)
+	}
+	if v, ok := ps.Get("missing"); v != "" || ok {
+		t.Errorf("missing param found: %q, %v", v, ok)
+	}
+}
+
+func TestParamsTyped(t *testing.T) {
+	ps := Params{
+		Param{"id", "42"},
+		Param{"neg", "-7"},
+		Param{"big", "18446744073709551615"},
+		Param{"uuid", "123E4567-e89b-12d3-a456-426614174000"},
+		Param{"name", "gopher"},
+	}
+
+	if v, err := ps.Int("id"); v != 42 || err != nil {
+		t.Errorf("wrong int: %d, %v", v, err)
+	}
+	if v, err := ps.Int64("neg"); v != -7 || err != nil {
+		t.Errorf("wrong int64: %d, %v", v, err)
+	}
+	if v, err := ps.Uint("id"); v != 42 || err != nil {
+		t.Errorf("wrong uint: %d, %v", v, err)
+	}
+	if v, err := ps.Uint64("big"); v != 18446744073709551615 || err != nil {
+		t.Errorf("wrong uint64: %d, %v", v, err)
+	}
+	if v, err := ps.UUID("uuid"); v != "123e4567-e89b-12d3-a456-426614174000" || err != nil {
+		t.Errorf("wrong UUID: %q, %v", v, err)
+	}
+
+	invalid := []struct {
+		name string
+		get  func(name string) error
+	}{
+		{"name", func(name string) error { _, err := ps.Int(name); return err }},
+		{"big", func(name string) error { _, err := ps.Int64(name); return err }},
+		{"neg", func(name string) error { _, err := ps.Uint(name); return err }},
+		{"neg", func(name string) error { _, err := ps.Uint64(name); return err }},
+		{"id", func(name string) error { _, err := ps.UUID(name); return err }},
+	}
+	for _, test := range invalid {
+		var he *HTTPError
+		if err := test.get(test.name); !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected HTTPError 400, got %v", test.name, err)
+		}
+	}
+	if _, err := ps.Int("missing"); !errors.Is(err, ErrMissingParam) {
+		t.Errorf("expected ErrMissingParam, got %v", err)
+	}
+
+	for _, s := range []string{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g", "{123e4567-e89b-12d3-a456-426614174000}"} {
+		if isUUID(s) {
+			t.Errorf("%q accepted as UUID", s)
+		}
+	}
+}
+
+func TestParamsDecode(t *testing.T) {
+	var repo struct {
+		Org  string   `param:"org"`
+		ID   uint64   `param:"id"`
+		Page *int     `param:"page"`
+		Name string   `query:"name"`
+		Tags []string `param:"tag"`
+	}
+	ps := Params{Param{"org", "heimdalr"}, Param{"id", "42"}, Param{"name", "x"}}
+	if err := ps.Decode(&repo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Org != "heimdalr" || repo.ID != 42 || repo.Page != nil || repo.Name != "" || repo.Tags != nil {
+		t.Errorf("wrong struct: %+v", repo)
+	}
+
+	err := Params{Param{"id", "x"}}.Decode(&repo)
+	var be *BindError
+	if !errors.As(err, &be) || len(be.Fields) != 1 || be.Fields[0].Field != "ID" || be.Fields[0].Source != "param" {
+		t.Errorf("expected BindError for field ID, got %v", err)
+	}
+
+	if recv := catchPanic(func() { _ = ps.Decode(repo) }); recv == nil {
+		t.Error("no panic for non-pointer")
+	}
+}
@@ -5,6 +5,8 @@
 
 package httprouter
 
+import "strings"
+
 // CleanPath is the URL version of path.Clean, it returns a canonical URL path
 // for p, eliminating . and .. elements.
 //
@@ -148,3 +150,50 @@ func bufApp(buf *[]byte, s string, w int, c byte) {
 	}
 	b[w] = c
 }
+
+// LowercasePath maps all letters of p to lower case. It can be used as a
+// PathNormalizer to make routing case-insensitive. Note that the values of path
+// parameters are affected as well.
+func LowercasePath(p string) string {
+	return strings.ToLower(p)
+}
+
+// TrimTrailingDots removes trailing dots from all path name elements of p, e.g.
+// "/abc./def.." becomes "/abc/def". Elements consisting of dots only (i.e. .
+// and .. elements) are left untouched, those are handled by CleanPath.
+func TrimTrailingDots(p string) string {
+	var buf []byte
+
+	for start, i := 0, 0; i <= len(p); i++ {
+		if i < len(p) && p[i] != '/' {
+			continue
+		}
+
+		// trim the element p[start:i], unless it consists of dots only
+		end := i
+		for end > start && p[end-1] == '.' {
+			end--
+		}
+		if end == start {
+			end = i
+		}
+
+		switch {
+		case buf != nil:
+			buf = append(buf, p[start:end]...)
+		case end != i:
+			// first modification, copy everything before
+			buf = make([]byte, 0, len(p))
+			buf = append(buf, p[:end]...)
+		}
+		if buf != nil && i < len(p) {
+			buf = append(buf, '/')
+		}
+		start = i + 1
+	}
+
+	if buf == nil {
+		return p
+	}
+	return string(buf)
+}
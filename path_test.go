@@ -147,3 +147,22 @@ func BenchmarkPathCleanLong(b *testing.B) {
 		}
 	}
 }
+
+func TestTrimTrailingDots(t *testing.T) {
+	tests := []cleanPathTest{
+		{"", ""},
+		{"/", "/"},
+		{"/abc/def", "/abc/def"},
+		{"/abc./def", "/abc/def"},
+		{"/abc/def.", "/abc/def"},
+		{"/abc../def.../", "/abc/def/"},
+		{"/a.b/c.", "/a.b/c"},
+		{"/./abc/..", "/./abc/.."},
+		{"/../abc.", "/../abc"},
+	}
+	for _, test := range tests {
+		if s := TrimTrailingDots(test.path); s != test.result {
+			t.Errorf("TrimTrailingDots(%q) = %q, want %q", test.path, s, test.result)
+		}
+	}
+}
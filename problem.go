@@ -0,0 +1,105 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem is a problem details object as defined by RFC 7807, i.e. a machine
+// readable description of an error, rendered as application/problem+json.
+// A Problem is an error itself, so it can be returned from a HandleE.
+//
+// see: https://tools.ietf.org/html/rfc7807
+type Problem struct {
+	// A URI reference identifying the problem type, "about:blank" if not set.
+	Type string
+	// A short, human-readable summary of the problem type.
+	Title string
+	// The HTTP status code.
+	Status int
+	// A human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// A URI reference identifying the specific occurrence of the problem.
+	Instance string
+	// Extension members, rendered alongside the members above.
+	Extensions map[string]interface{}
+}
+
+// NewProblem returns a new Problem of type "about:blank" with the given status
+// code, the status text as title and the given detail (which may be empty).
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// NewTypedProblem returns a new Problem with the given type, title, status code
+// and detail (which may be empty).
+func NewTypedProblem(typ, title string, status int, detail string) *Problem {
+	return &Problem{
+		Type:   typ,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With adds the given extension member to the problem and returns the problem.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// Error returns the detail of the problem or, if there is none, its title.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// MarshalJSON renders the problem as JSON object, extension members named like
+// one of the standard members are ignored.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+	m["type"] = typ
+	delete(m, "title")
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	delete(m, "status")
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	delete(m, "detail")
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	delete(m, "instance")
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// writeProblem writes the given problem to the response as
+// application/problem+json, with the status code of the problem.
+func writeProblem(w http.ResponseWriter, p *Problem) {
+	bytes, _ := json.Marshal(p)
+	w.Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(bytes)
+}
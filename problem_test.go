@@ -0,0 +1,116 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestProblemMarshalJSON(t *testing.T) {
+	p := NewProblem(http.StatusForbidden, "not your account").
+		With("balance", 30).
+		With("status", "ignored")
+	p.Instance = "/account/12345"
+
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	_ = json.Unmarshal(bytes, &got)
+	want := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Forbidden",
+		"status":   float64(http.StatusForbidden),
+		"detail":   "not your account",
+		"instance": "/account/12345",
+		"balance":  float64(30),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong problem JSON: want %v, got %v", want, got)
+	}
+	if p.Error() != "not your account" {
+		t.Errorf("wrong problem error: %s", p.Error())
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("cause")
+	e := NewHTTPError(http.StatusBadRequest, cause)
+	e.Details = []string{"name"}
+
+	if !errors.Is(e, cause) || errors.Unwrap(e) != cause {
+		t.Error("HTTPError does not wrap its cause")
+	}
+	bytes, _ := json.Marshal(e)
+	if want := `{"code":400,"error":"cause","details":["name"]}`; string(bytes) != want {
+		t.Errorf("wrong HTTPError JSON: want %s, got %s", want, bytes)
+	}
+	if msg := NewHTTPError(http.StatusNotFound, nil).Error(); msg != "Not Found" {
+		t.Errorf("wrong HTTPError message: %s", msg)
+	}
+}
+
+func TestRouterProblemDetails(t *testing.T) {
+	logger := zerolog.Nop()
+	router := New()
+	router.Logger = &logger
+	router.ProblemDetails = true
+	router.HandleMethodNotAllowed = true
+	router.PanicHandler = router.DefaultPanicHandler
+	router.POST("/path", func(_ *Context) {})
+	router.GET("/panic", func(_ *Context) {
+		panic("oops")
+	})
+	router.GET("/invalid", HandleE(func(_ *Context) error {
+		e := NewHTTPError(http.StatusBadRequest, errors.New("invalid name"))
+		e.Details = "name"
+		return e
+	}).Handle())
+	router.GET("/problem", HandleE(func(_ *Context) error {
+		return NewTypedProblem("https://example.com/out-of-credit", "Out of credit", http.StatusForbidden, "")
+	}).Handle())
+
+	testRoutes := []struct {
+		method string
+		route  string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound,
+			`{"instance":"/nope","status":404,"title":"Not Found","type":"about:blank"}`},
+		{http.MethodGet, "/path", http.StatusMethodNotAllowed,
+			`{"instance":"/path","status":405,"title":"Method Not Allowed","type":"about:blank"}`},
+		{http.MethodGet, "/panic", http.StatusInternalServerError,
+			`{"instance":"/panic","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+		{http.MethodGet, "/invalid", http.StatusBadRequest,
+			`{"detail":"invalid name","details":"name","instance":"/invalid","status":400,"title":"Bad Request","type":"about:blank"}`},
+		{http.MethodGet, "/problem", http.StatusForbidden,
+			`{"status":403,"title":"Out of credit","type":"https://example.com/out-of-credit"}`},
+	}
+	for _, tr := range testRoutes {
+		r, _ := http.NewRequest(tr.method, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tr.code || w.Body.String() != tr.body {
+			t.Errorf("route %s: want %d %s, got %d %s", tr.route, tr.code, tr.body, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get(HeaderContentType); ct != MIMEApplicationProblemJSON {
+			t.Errorf("route %s: wrong content type %s", tr.route, ct)
+		}
+	}
+
+	// without ProblemDetails, the default responses stay plain JSON
+	router.ProblemDetails = false
+	r, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if body := w.Body.String(); w.Code != http.StatusInternalServerError || body != `{"error":"Internal Server Error"}` {
+		t.Errorf("default panic response: got %d %s", w.Code, body)
+	}
+}
@@ -0,0 +1,164 @@
+package httprouter
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// QueryValues returns the parsed query string of the request. The query string
+// is parsed once per request, the returned values must not be modified.
+func (c *Context) QueryValues() url.Values {
+	if c.query == nil {
+		c.query = c.Request.URL.Query()
+	}
+	return c.query
+}
+
+// QueryString returns the first value of the query parameter with the given
+// name, or def if the parameter is missing or empty.
+func (c *Context) QueryString(name, def string) string {
+	if v := c.QueryValues().Get(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// QueryInt returns the first value of the query parameter with the given name
+// as int, or def if the parameter is missing or empty. If the value isn't an
+// integer, an HTTPError with status code 400 is returned.
+func (c *Context) QueryInt(name string, def int) (int, error) {
+	v := c.QueryValues().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def, queryError(name, errors.New("invalid integer"))
+	}
+	return i, nil
+}
+
+// QueryBool returns the first value of the query parameter with the given name
+// as bool (see strconv.ParseBool), or def if the parameter is missing or empty.
+// If the value isn't a boolean, an HTTPError with status code 400 is returned.
+func (c *Context) QueryBool(name string, def bool) (bool, error) {
+	v := c.QueryValues().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, queryError(name, errors.New("invalid boolean"))
+	}
+	return b, nil
+}
+
+// QueryTime returns the first value of the query parameter with the given name
+// parsed with the given layout (e.g. time.RFC3339), or def if the parameter is
+// missing or empty. If the value can't be parsed, an HTTPError with status code
+// 400 is returned.
+func (c *Context) QueryTime(name, layout string, def time.Time) (time.Time, error) {
+	v := c.QueryValues().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return def, queryError(name, errors.New("invalid time"))
+	}
+	return t, nil
+}
+
+// QuerySlice returns all values of the query parameter with the given name
+// (e.g. ["a", "b"] for "?tag=a&tag=b"), or def if the parameter is missing.
+func (c *Context) QuerySlice(name string, def []string) []string {
+	if vs, ok := c.QueryValues()[name]; ok {
+		return vs
+	}
+	return def
+}
+
+// queryError returns an HTTPError with status code 400 for the query parameter
+// with the given name.
+func queryError(name string, err error) error {
+	fields := []FieldError{{Field: name, Source: "query", Name: name, Err: err}}
+	return &HTTPError{Code: http.StatusBadRequest, Err: &BindError{Fields: fields}, Details: fields}
+}
+
+// QueryParser reads typed query parameters, collecting conversion errors
+// instead of failing on the first, see Context.QueryParser.
+type QueryParser struct {
+	c      *Context
+	fields []FieldError
+}
+
+// QueryParser returns a QueryParser for the query string of the request. Its
+// getters return the default value for invalid values and collect the errors,
+// which are returned by Err:
+//
+//	q := c.QueryParser()
+//	page := q.Int("page", 1)
+//	since := q.Time("since", time.RFC3339, time.Time{})
+//	if err := q.Err(); err != nil {
+//		return err
+//	}
+func (c *Context) QueryParser() *QueryParser {
+	return &QueryParser{c: c}
+}
+
+// collect records the error of a getter, if any.
+func (q *QueryParser) collect(err error) {
+	var he *HTTPError
+	var be *BindError
+	if errors.As(err, &he) && errors.As(he.Err, &be) {
+		q.fields = append(q.fields, be.Fields...)
+	}
+}
+
+// String returns the query parameter with the given name, see
+// Context.QueryString.
+func (q *QueryParser) String(name, def string) string {
+	return q.c.QueryString(name, def)
+}
+
+// Int returns the query parameter with the given name as int, see
+// Context.QueryInt.
+func (q *QueryParser) Int(name string, def int) int {
+	i, err := q.c.QueryInt(name, def)
+	q.collect(err)
+	return i
+}
+
+// Bool returns the query parameter with the given name as bool, see
+// Context.QueryBool.
+func (q *QueryParser) Bool(name string, def bool) bool {
+	b, err := q.c.QueryBool(name, def)
+	q.collect(err)
+	return b
+}
+
+// Time returns the query parameter with the given name as time.Time, see
+// Context.QueryTime.
+func (q *QueryParser) Time(name, layout string, def time.Time) time.Time {
+	t, err := q.c.QueryTime(name, layout, def)
+	q.collect(err)
+	return t
+}
+
+// Slice returns all values of the query parameter with the given name, see
+// Context.QuerySlice.
+func (q *QueryParser) Slice(name string, def []string) []string {
+	return q.c.QuerySlice(name, def)
+}
+
+// Err returns an HTTPError with status code 400 wrapping a BindError which
+// lists all invalid query parameters, or nil if there were none.
+func (q *QueryParser) Err() error {
+	if len(q.fields) == 0 {
+		return nil
+	}
+	return &HTTPError{Code: http.StatusBadRequest, Err: &BindError{Fields: q.fields}, Details: q.fields}
+}
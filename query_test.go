@@ -0,0 +1,97 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContextQuery(t *testing.T) {
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/?name=gopher&page=2&debug=true&since=2020-01-02T03:04:05Z&tag=a&tag=b&empty=&bad=x", nil)}
+
+	if v := c.QueryString("name", "none"); v != "gopher" {
+		t.Errorf("wrong string: %q", v)
+	}
+	if v := c.QueryString("empty", "none"); v != "none" {
+		t.Errorf("wrong default for empty string: %q", v)
+	}
+	if v, err := c.QueryInt("page", 1); v != 2 || err != nil {
+		t.Errorf("wrong int: %d, %v", v, err)
+	}
+	if v, err := c.QueryInt("missing", 1); v != 1 || err != nil {
+		t.Errorf("wrong default int: %d, %v", v, err)
+	}
+	if v, err := c.QueryBool("debug", false); !v || err != nil {
+		t.Errorf("wrong bool: %v, %v", v, err)
+	}
+	since, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+	if v, err := c.QueryTime("since", time.RFC3339, time.Time{}); !v.Equal(since) || err != nil {
+		t.Errorf("wrong time: %v, %v", v, err)
+	}
+	if v := c.QuerySlice("tag", nil); !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Errorf("wrong slice: %v", v)
+	}
+	if v := c.QuerySlice("missing", []string{"x"}); !reflect.DeepEqual(v, []string{"x"}) {
+		t.Errorf("wrong default slice: %v", v)
+	}
+
+	var he *HTTPError
+	if v, err := c.QueryInt("bad", 7); v != 7 || !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+		t.Errorf("expected default and HTTPError 400 for invalid int, got %d, %v", v, err)
+	}
+
+	// the query string is parsed once
+	c.Request.URL.RawQuery = "name=other"
+	if v := c.QueryString("name", ""); v != "gopher" {
+		t.Errorf("query string parsed again: %q", v)
+	}
+}
+
+func TestContextQueryParser(t *testing.T) {
+	router := New()
+	router.GET("/search", HandleE(func(c *Context) error {
+		q := c.QueryParser()
+		page := q.Int("page", 1)
+		debug := q.Bool("debug", false)
+		since := q.Time("since", time.RFC3339, time.Time{})
+		tags := q.Slice("tag", nil)
+		term := q.String("q", "")
+		if err := q.Err(); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"page": page, "debug": debug, "since": since.Year(), "tags": tags, "q": term})
+	}).Handle())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/search?page=3&debug=1&since=2020-01-02T03:04:05Z&tag=a&q=go", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"debug\":true,\"page\":3,\"q\":\"go\",\"since\":2020,\"tags\":[\"a\"]}\n" {
+		t.Errorf("wrong response: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/search?page=x&debug=maybe&since=yesterday", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("wrong status code: want 400, got %d", w.Code)
+	}
+	var body struct {
+		Details []struct {
+			Field string `json:"field"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", w.Body.String(), err)
+	}
+	var fields []string
+	for _, d := range body.Details {
+		fields = append(fields, d.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"page", "debug", "since"}) {
+		t.Errorf("wrong fields: %v", fields)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package httprouter
+
+// raceEnabled reports whether the race detector is enabled, which makes
+// sync.Pool drop objects at random.
+const raceEnabled = true
@@ -0,0 +1,168 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// Renderer renders named templates, see Router.Renderer and Context.Render.
+type Renderer interface {
+	// Render writes the template with the given name, executed with the given
+	// data, to w. The context is that of the request being answered.
+	Render(w io.Writer, name string, data interface{}, c *Context) error
+}
+
+// errNoRenderer is returned by Context.Render if Router.Renderer is not set.
+var errNoRenderer = errors.New("no renderer set")
+
+// Render renders the template with the given name and data with the
+// Router.Renderer as text/html with the given status code. If the template
+// can't be rendered, the error is returned and nothing is written.
+func (c *Context) Render(code int, name string, data interface{}) error {
+	if c.router == nil || c.router.Renderer == nil {
+		return errNoRenderer
+	}
+	var buf bytes.Buffer
+	if err := c.router.Renderer.Render(&buf, name, data, c); err != nil {
+		return err
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
+// HTMLRenderer is a Renderer for html/template templates read from a directory.
+// Templates are named by their slash separated path relative to the directory,
+// e.g. "users/show.html".
+//
+// A page is rendered by executing the Layout (if set), which includes the
+// blocks defined by the page, e.g. with {{template "content" .}}. Without
+// layout, the page is executed itself. Layout and page can include partials
+// by name, e.g. {{template "partials/nav.html" .}}.
+//
+// Besides the Funcs, templates can use the function "url", which returns the
+// path of a route of the router serving the request like Router.URL, e.g.
+// {{url "/users/:id" .ID}} or, for a named route, {{url "user" .ID}}.
+//
+// Templates are parsed on first use and cached, unless Reload is set.
+type HTMLRenderer struct {
+	// The directory the templates are read from.
+	Dir string
+	// The name of the layout template, empty if pages are rendered without.
+	Layout string
+	// Glob patterns of the names of the partial templates, e.g. "partials/*.html".
+	Partials []string
+	// Functions available to all templates in addition to "url".
+	Funcs template.FuncMap
+	// If set, templates are read from disk on every render (e.g. for development).
+	Reload bool
+
+	lock  sync.RWMutex
+	cache map[string]*template.Template
+}
+
+// NewHTMLRenderer returns a new HTMLRenderer reading templates from the given
+// directory.
+func NewHTMLRenderer(dir string) *HTMLRenderer {
+	return &HTMLRenderer{Dir: dir}
+}
+
+// Render implements the Renderer interface.
+func (hr *HTMLRenderer) Render(w io.Writer, name string, data interface{}, c *Context) error {
+	t, err := hr.template(name)
+	if err != nil {
+		return err
+	}
+	// bind the url func to the router of the request; cached templates are
+	// never executed themselves, such that they can be cloned
+	var r *Router
+	if c != nil {
+		r = c.router
+	}
+	if t, err = t.Clone(); err != nil {
+		return err
+	}
+	t.Funcs(template.FuncMap{"url": urlFunc(r)})
+	if hr.Layout != "" {
+		return t.ExecuteTemplate(w, hr.Layout, data)
+	}
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// template returns the parsed template set for the page with the given name.
+func (hr *HTMLRenderer) template(name string) (*template.Template, error) {
+	if hr.Reload {
+		return hr.parse(name)
+	}
+
+	hr.lock.RLock()
+	t, ok := hr.cache[name]
+	hr.lock.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	t, err := hr.parse(name)
+	if err != nil {
+		return nil, err
+	}
+	hr.lock.Lock()
+	if hr.cache == nil {
+		hr.cache = make(map[string]*template.Template)
+	}
+	hr.cache[name] = t
+	hr.lock.Unlock()
+	return t, nil
+}
+
+// parse parses the layout, the partials and the page with the given name into
+// a template set.
+func (hr *HTMLRenderer) parse(name string) (*template.Template, error) {
+	var names []string
+	if hr.Layout != "" {
+		names = append(names, hr.Layout)
+	}
+	for _, pattern := range hr.Partials {
+		matches, err := filepath.Glob(filepath.Join(hr.Dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(hr.Dir, match)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+	}
+	names = append(names, name)
+
+	// the first template is the root of the set
+	t := template.New(names[0]).Funcs(template.FuncMap{"url": urlFunc(nil)}).Funcs(hr.Funcs)
+	for i, n := range names {
+		b, err := ioutil.ReadFile(filepath.Join(hr.Dir, filepath.FromSlash(path.Clean("/"+n))))
+		if err != nil {
+			return nil, err
+		}
+		tn := t
+		if i > 0 {
+			tn = t.New(n)
+		}
+		if _, err = tn.Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// urlFunc returns the "url" template func building the paths of the routes of
+// the given router (see Router.URL), which may be nil.
+func urlFunc(r *Router) func(string, ...interface{}) (string, error) {
+	return func(route string, values ...interface{}) (string, error) {
+		return r.buildURL(route, values)
+	}
+}
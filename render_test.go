@@ -0,0 +1,123 @@
+package httprouter
+
+import (
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRouterURL(t *testing.T) {
+	router := New()
+	tests := []struct {
+		route  string
+		values []interface{}
+		want   string
+	}{
+		{"/", nil, "/"},
+		{"/users/:id", []interface{}{42}, "/users/42"},
+		{"/users/:id/posts/:post", []interface{}{"a b", "c/d"}, "/users/a%20b/posts/c%2Fd"},
+		{"/files/*filepath", []interface{}{"/docs/a b.txt"}, "/files/docs/a%20b.txt"},
+	}
+	for _, test := range tests {
+		if got := router.URL(test.route, test.values...); got != test.want {
+			t.Errorf("%s %v: want %q, got %q", test.route, test.values, test.want, got)
+		}
+	}
+
+	if recv := catchPanic(func() { router.URL("/users/:id") }); recv == nil {
+		t.Error("no panic for missing value")
+	}
+	if recv := catchPanic(func() { router.URL("/users", 1) }); recv == nil {
+		t.Error("no panic for too many values")
+	}
+}
+
+func TestContextRender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"layout.html":        `<html>{{template "partials/nav.html" .}}{{template "content" .}}</html>`,
+		"partials/nav.html":  `<nav>{{shout "nav"}}{{url "/broken"}}</nav>`,
+		"users/show.html":    `{{define "content"}}<a href="{{url "user" .ID}}">{{.Name}}</a>{{end}}`,
+		"users/broken.html":  `{{define "content"}}{{.Missing.Field}}{{end}}`,
+		"partials/other.txt": `not a partial`,
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renderer := NewHTMLRenderer(dir)
+	renderer.Layout = "layout.html"
+	renderer.Partials = []string{"partials/*.html"}
+	renderer.Funcs = template.FuncMap{"shout": strings.ToUpper}
+
+	router := New()
+	router.GET("/users/:id", HandleE(func(c *Context) error {
+		return c.Render(http.StatusOK, "users/show.html", map[string]interface{}{"ID": c.Params.ByName("id"), "Name": "<gopher>"})
+	}).Handle()).WithName("user")
+	router.GET("/broken", HandleE(func(c *Context) error {
+		return c.Render(http.StatusOK, "users/broken.html", 42)
+	}).Handle())
+
+	render := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// without renderer
+	if w := render("/users/1"); w.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status code without renderer: want 500, got %d", w.Code)
+	}
+
+	router.Renderer = renderer
+	w := render("/users/a b")
+	want := `<html><nav>NAV/broken</nav><a href="/users/a%20b">&lt;gopher&gt;</a></html>`
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("wrong response: want 200 %q, got %d %q", want, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(HeaderContentType); got != MIMETextHTMLCharsetUTF8 {
+		t.Errorf("wrong content type: want %q, got %q", MIMETextHTMLCharsetUTF8, got)
+	}
+	if w = render("/broken"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "<html>") {
+		t.Errorf("expected 500 without partial output for failing template, got %d %q", w.Code, w.Body.String())
+	}
+
+	// templates are cached unless reloading
+	page := filepath.Join(dir, "users", "show.html")
+	if err = ioutil.WriteFile(page, []byte(`{{define "content"}}changed{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w = render("/users/1"); w.Body.String() != `<html><nav>NAV/broken</nav><a href="/users/1">&lt;gopher&gt;</a></html>` {
+		t.Errorf("cached template not used, got %q", w.Body.String())
+	}
+	renderer.Reload = true
+	if w = render("/users/1"); w.Body.String() != `<html><nav>NAV/broken</nav>changed</html>` {
+		t.Errorf("template not reloaded, got %q", w.Body.String())
+	}
+
+	// missing templates
+	c := &Context{router: router}
+	if err = renderer.Render(ioutil.Discard, "missing.html", nil, c); err == nil {
+		t.Error("no error for missing template")
+	}
+	if err = (&Context{}).Render(http.StatusOK, "users/show.html", nil); !errors.Is(err, errNoRenderer) {
+		t.Errorf("expected errNoRenderer, got %v", err)
+	}
+}
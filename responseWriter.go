@@ -53,6 +53,19 @@ type responseWriter struct {
 	beforeFuncs []beforeFunc
 }
 
+// reset makes the response writer wrap the given http.ResponseWriter, clearing
+// all state but keeping the allocated memory.
+func (rw *responseWriter) reset(w http.ResponseWriter) {
+	rw.ResponseWriter = w
+	rw.status = 0
+	rw.size = 0
+	rw.error = nil
+	for i := range rw.beforeFuncs {
+		rw.beforeFuncs[i] = nil
+	}
+	rw.beforeFuncs = rw.beforeFuncs[:0]
+}
+
 func (rw *responseWriter) WriteHeader(s int) {
 	rw.status = s
 	rw.callBefore()
@@ -101,7 +114,13 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if !ok {
 		return nil, nil, errors.New("the ResponseWriter doesn't support the Hijacker interface")
 	}
-	return hijacker.Hijack()
+	conn, brw, err := hijacker.Hijack()
+	if err == nil && !rw.Written() {
+		// the connection was taken over (e.g. for a protocol switch), record the
+		// response as written such that nothing is written anymore
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
 }
 
 func (rw *responseWriter) callBefore() {
@@ -131,4 +150,19 @@ type responseWriterCloseNotifer struct {
 
 func (rw *responseWriterCloseNotifer) CloseNotify() <-chan bool {
 	return rw.ResponseWriter.(http.CloseNotifier).CloseNotify()
-}
\ No newline at end of file
+}
+
+// releasedResponseWriter is the ResponseWriter of poisoned context objects, it
+// panics on every call (see ContextDebug).
+type releasedResponseWriter struct{}
+
+func (releasedResponseWriter) Header() http.Header               { panic(releasedContextPanic) }
+func (releasedResponseWriter) Write([]byte) (int, error)         { panic(releasedContextPanic) }
+func (releasedResponseWriter) WriteHeader(int)                   { panic(releasedContextPanic) }
+func (releasedResponseWriter) Flush()                            { panic(releasedContextPanic) }
+func (releasedResponseWriter) Status() int                       { panic(releasedContextPanic) }
+func (releasedResponseWriter) Written() bool                     { panic(releasedContextPanic) }
+func (releasedResponseWriter) Size() int                         { panic(releasedContextPanic) }
+func (releasedResponseWriter) Before(func(ResponseWriter))       { panic(releasedContextPanic) }
+func (releasedResponseWriter) Error() error                      { panic(releasedContextPanic) }
+func (releasedResponseWriter) WriteHeaderError(s int, err error) { panic(releasedContextPanic) }
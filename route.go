@@ -0,0 +1,62 @@
+package httprouter
+
+// Route is a registered route, as returned by the registration methods (e.g.
+// Router.GET). The route matched by a request is available as Context.Route.
+type Route struct {
+	// The method the route was registered for.
+	Method string
+	// The path the route was registered with, e.g. "/users/:id".
+	Path string
+	// The name of the route, see WithName.
+	Name string
+	// Metadata attached to the route, see WithMeta.
+	Meta map[string]interface{}
+
+	router *Router
+}
+
+// WithName names the route, such that its path can be built by name with
+// Router.URL, and returns the route. Names must be unique per router.
+//
+//	router.GET("/users/:id", showUser).WithName("user")
+func (rt *Route) WithName(name string) *Route {
+	if name == "" || name[0] == '/' {
+		panic("route name must not be empty or begin with '/'")
+	}
+	r := rt.router
+	if _, ok := r.namedRoutes[name]; ok {
+		panic("a route is already named '" + name + "'")
+	}
+	if r.namedRoutes == nil {
+		r.namedRoutes = make(map[string]*Route)
+	}
+	if rt.Name != "" {
+		delete(r.namedRoutes, rt.Name)
+	}
+	rt.Name = name
+	r.namedRoutes[name] = rt
+	return rt
+}
+
+// WithMeta attaches the given metadata to the route and returns the route.
+//
+//	router.DELETE("/users/:id", deleteUser).WithMeta("role", "admin")
+func (rt *Route) WithMeta(key string, value interface{}) *Route {
+	if rt.Meta == nil {
+		rt.Meta = make(map[string]interface{})
+	}
+	rt.Meta[key] = value
+	return rt
+}
+
+// Routes returns all registered routes in order of registration.
+func (r *Router) Routes() []*Route {
+	routes := make([]*Route, len(r.routes))
+	copy(routes, r.routes)
+	return routes
+}
+
+// Route returns the route with the given name, nil if there is none.
+func (r *Router) Route(name string) *Route {
+	return r.namedRoutes[name]
+}
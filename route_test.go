@@ -0,0 +1,78 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextRoute(t *testing.T) {
+	var got *Route
+	handle := func(c *Context) { got = c.Route }
+
+	router := New()
+	router.GET("/users/:id", handle).WithName("user").WithMeta("auth", true)
+	router.Group("/api").POST("/items/*path", handle)
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	if got == nil {
+		t.Fatal("route not set")
+	}
+	if got.Method != http.MethodGet || got.Path != "/users/:id" || got.Name != "user" || got.Meta["auth"] != true {
+		t.Errorf("wrong route: %+v", got)
+	}
+
+	got = nil
+	r = httptest.NewRequest(http.MethodPost, "/api/items/a/b", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	if got == nil || got.Method != http.MethodPost || got.Path != "/api/items/*path" || got.Name != "" {
+		t.Errorf("wrong route: %+v", got)
+	}
+
+	// redirected requests are dispatched with the route of the target
+	got = nil
+	r = httptest.NewRequest(http.MethodGet, "/users/42/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if got != nil || w.Code != http.StatusMovedPermanently {
+		t.Errorf("unexpected dispatch: %d, %+v", w.Code, got)
+	}
+}
+
+func TestRouterRoutes(t *testing.T) {
+	handle := func(_ *Context) {}
+	router := New()
+	user := router.GET("/users/:id", handle).WithName("user")
+	router.PUT("/users/:id", handle)
+
+	routes := router.Routes()
+	if len(routes) != 2 || routes[0] != user || routes[1].Method != http.MethodPut {
+		t.Fatalf("wrong routes: %+v", routes)
+	}
+	if router.Route("user") != user || router.Route("missing") != nil {
+		t.Error("wrong route by name")
+	}
+	if url := router.URL("user", 42); url != "/users/42" {
+		t.Errorf("wrong URL by name: %s", url)
+	}
+
+	// renaming frees the old name
+	user.WithName("member")
+	if router.Route("user") != nil || router.Route("member") != user {
+		t.Error("renaming failed")
+	}
+
+	recv := catchPanic(func() {
+		router.GET("/members/:id", handle).WithName("member")
+	})
+	if recv == nil {
+		t.Error("no panic for duplicate route name")
+	}
+	recv = catchPanic(func() {
+		router.URL("missing")
+	})
+	if recv == nil {
+		t.Error("no panic for unknown route name")
+	}
+}
@@ -79,10 +79,14 @@ package httprouter
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Handle is a function that can be registered to a route to handle HTTP
@@ -90,6 +94,31 @@ import (
 // response, params, etc.
 type Handle func(c *Context)
 
+// HandleE is a Handle returning an error. Returned errors are handed to the
+// ErrorHandler of the context object, with status code 500 unless the error is
+// (or wraps) an HTTPError or a Problem, or is mapped (see Router.MapError).
+// A HandleE is registered as the Handle returned by its Handle method.
+type HandleE func(c *Context) error
+
+// Handle returns a Handle calling the HandleE and handing a returned error to
+// the ErrorHandler of the context object, such that the HandleE can be
+// registered:
+//
+//	router.GET("/users/:id", httprouter.HandleE(getUser).Handle())
+func (h HandleE) Handle() Handle {
+	if h == nil {
+		return nil
+	}
+	return h.handle
+}
+
+// handle calls the HandleE, handing a returned error to the ErrorHandler.
+func (h HandleE) handle(c *Context) {
+	if err := h(c); err != nil {
+		c.Error(0, err)
+	}
+}
+
 // Param is a single URL parameter, consisting of a key and a value.
 type Param struct {
 	Key   string
@@ -112,6 +141,17 @@ func (ps Params) ByName(name string) string {
 	return ""
 }
 
+// Get returns the value of the first Param which key matches the given name
+// and whether such a Param was found.
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
 type paramsKey struct{}
 
 // ParamsKey is the request context key under which URL params are stored.
@@ -126,11 +166,15 @@ func ParamsFromContext(ctx context.Context) Params {
 
 // MatchedRoutePathParam is the Param name under which the path of the matched
 // route is stored, if Router.SaveMatchedRoutePath is set.
+//
+// Deprecated: use Context.Route, which is always set.
 var MatchedRoutePathParam = "$matchedRoutePath"
 
 // MatchedRoutePath retrieves the path of the matched route.
 // Router.SaveMatchedRoutePath must have been enabled when the respective
 // handler was added, otherwise this function always returns an empty string.
+//
+// Deprecated: use Context.Route, which is always set.
 func (ps Params) MatchedRoutePath() string {
 	return ps.ByName(MatchedRoutePathParam)
 }
@@ -140,6 +184,10 @@ func (ps Params) MatchedRoutePath() string {
 type Router struct {
 	trees map[string]*node
 
+	// all routes in order of registration and the named routes
+	routes      []*Route
+	namedRoutes map[string]*Route
+
 	paramsPool sync.Pool
 	maxParams  uint16
 
@@ -147,15 +195,49 @@ type Router struct {
 	// before invoking the handler.
 	// The matched route path is only added to handlers of routes that were
 	// registered when this option was enabled.
+	//
+	// Deprecated: the matched route is available as Context.Route.
 	SaveMatchedRoutePath bool
 
-	// Enables automatic redirection if the current route can't be matched but a
-	// handler for the path with (without) the trailing slash exists.
+	// Defines how requests are treated if the current route can't be matched
+	// but a handler for the path with (without) the trailing slash exists.
 	// For example if /foo/ is requested but a route only exists for /foo, the
-	// client is redirected to /foo with http status code 301 for GET requests
-	// and 308 for all other request methods.
+	// request is answered with 404 (TrailingSlashStrict), the client is
+	// redirected to /foo (TrailingSlashRedirect) or the request is served by the
+	// handler for /foo (TrailingSlashTolerant).
+	// The policy can be overridden for a group of routes with
+	// Group.SetTrailingSlash.
+	TrailingSlash TrailingSlashPolicy
+
+	// Enables automatic redirection if the current route can't be matched but a
+	// handler for the path with (without) the trailing slash exists. If
+	// disabled, the TrailingSlashRedirect policy of the router (not of groups)
+	// is treated as TrailingSlashStrict.
+	//
+	// Deprecated: set TrailingSlash instead.
 	RedirectTrailingSlash bool
 
+	// The status code used for trailing slash redirects. If not set, 301 is used
+	// for GET requests and 308 for all other request methods.
+	TrailingSlashRedirectCode int
+
+	// Trailing slash policies set for groups, keyed by the group prefix
+	trailingSlash map[string]TrailingSlashPolicy
+
+	// Functions the request path is passed through, in order, before it is
+	// looked up, e.g. CleanPath, LowercasePath or TrimTrailingDots.
+	// If the normalized path differs from the request path, the request is
+	// rewritten to the normalized path internally, unless
+	// RedirectNormalizedPath is set. The original request path is available as
+	// Context.OriginalPath.
+	// Default: none
+	PathNormalizers []PathNormalizer
+
+	// If enabled, requests whose path was changed by PathNormalizers are
+	// redirected to the normalized path with status code 301 for GET requests
+	// and 308 for all other request methods instead of being rewritten.
+	RedirectNormalizedPath bool
+
 	// If enabled, the router tries to fix the current request path, if no
 	// handle is registered for it.
 	// First superfluous path elements like ../ or // are removed.
@@ -164,9 +246,17 @@ type Router struct {
 	// to the corrected path with status code 301 for GET requests and 308 for
 	// all other request methods.
 	// For example /FOO and /..//Foo could be redirected to /foo.
-	// RedirectTrailingSlash is independent of this option.
+	// TrailingSlash is independent of this option.
 	RedirectFixedPath bool
 
+	// If enabled, the router serves requests for which the case-insensitive
+	// lookup described above succeeds directly instead of redirecting the
+	// client. Path parameters are extracted from the original request path and
+	// the corrected path is made available as Context.CanonicalPath.
+	// For example a GET for /FOO is served by the handle registered for /foo.
+	// Takes precedence over RedirectFixedPath.
+	ServeFixedPath bool
+
 	// If enabled, the router automatically replies to OPTIONS requests.
 	// Path-specific OPTIONS handlers take priority over "automatic" replies.
 	//
@@ -203,6 +293,50 @@ type Router struct {
 	// found. If not set, http.NotFound is used.
 	NotFound func(http.ResponseWriter, *http.Request)
 
+	// If enabled, the default responses for 404 (Not Found), 405 (Method Not
+	// Allowed), panics (see DefaultPanicHandler) and errors (see
+	// DefaultErrorHandler) are rendered as RFC 7807 problem details
+	// (application/problem+json).
+	// Default: false
+	ProblemDetails bool
+
+	// A function rendering the errors passed to Context.Error and returned by
+	// HandleE handles, set as Context.ErrorHandler for every request.
+	// If not set, DefaultErrorHandler is used.
+	ErrorHandler func(status int, err error, c *Context)
+
+	// Problems registered for errors, see MapError and MapErrorType
+	errorMappings []errorMapping
+
+	// Codecs registered for content types, see RegisterCodec
+	codecs map[string]Codec
+
+	// The validator used by Context.BindAndValidate.
+	// If not set, a validator with the built-in rules is used.
+	Validator *Validator
+
+	// The renderer used by Context.Render, e.g. an HTMLRenderer.
+	Renderer Renderer
+
+	// The maximum number of bytes of multipart request bodies kept in memory
+	// when parsed (see Context.FormFile and Context.Bind), the remainder is
+	// stored in temporary files, which are removed after the handler returned.
+	// Default: 32 MB
+	MaxMultipartMemory int64
+
+	// The maximum size in bytes of multipart request bodies parsed by
+	// Context.FormFile and Context.Bind, larger bodies are answered with
+	// status code 413 (Request Entity Too Large). Negative values disable the
+	// limit.
+	// Default: 128 MB
+	MaxMultipartSize int64
+
+	// The interval in which event streams (see Context.SSE) send keep-alive
+	// comments, preventing proxies from closing idle connections. Negative
+	// values disable keep-alive comments.
+	// Default: 15 seconds
+	SSEKeepAlive time.Duration
+
 	// Function to handle panics recovered from http handlers.
 	// It should be used to generate a error page and return the http error code
 	// 500 (Internal Server Error).
@@ -210,11 +344,47 @@ type Router struct {
 	// unrecovered panics.
 	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
 
+	// The logger the per-request loggers (Context.Logger) are derived from.
+	// Per-request loggers carry the request ID, the method, the matched route
+	// and the remote IP, plus the fields added by LoggerFields.
+	// If not set, the global logger of github.com/rs/zerolog/log is used.
+	// If the logger is disabled, requests are served without allocations.
+	Logger *zerolog.Logger
+
+	// Functions adding custom fields to the per-request loggers, called in
+	// order for every request.
+	LoggerFields []LoggerFieldsFunc
+
 	// Cached value of global (*) allowed methods
 	globalAllowed string
 
 }
 
+// PathNormalizer is a function normalizing a request path, see
+// Router.PathNormalizers.
+type PathNormalizer func(path string) string
+
+// LoggerFieldsFunc is a function adding fields to a per-request logger, see
+// Router.LoggerFields.
+type LoggerFieldsFunc func(c *Context, l zerolog.Context) zerolog.Context
+
+// TrailingSlashPolicy defines how the router treats requests which can't be
+// matched but would match with (without) a trailing slash.
+type TrailingSlashPolicy uint8
+
+const (
+	// TrailingSlashStrict answers such requests with 404 (Not Found).
+	TrailingSlashStrict TrailingSlashPolicy = iota
+
+	// TrailingSlashRedirect redirects the client to the path with (without) the
+	// trailing slash, preserving the query string.
+	TrailingSlashRedirect
+
+	// TrailingSlashTolerant transparently serves such requests with the handle
+	// registered for the path with (without) the trailing slash.
+	TrailingSlashTolerant
+)
+
 // Make sure the Router conforms with the http.Handler interface
 var _ http.Handler = New()
 
@@ -222,6 +392,7 @@ var _ http.Handler = New()
 // Path auto-correction, including trailing slashes, is enabled by default.
 func New() *Router {
 	return &Router{
+		TrailingSlash:          TrailingSlashRedirect,
 		RedirectTrailingSlash:  true,
 		RedirectFixedPath:      true,
 		HandleOptions: 			false,
@@ -258,38 +429,38 @@ func (r *Router) saveMatchedRoutePath(path string, handle Handle) Handle {
 }
 
 // GET is a shortcut for router.Handle(http.MethodGet, path, handle)
-func (r *Router) GET(path string, handle Handle) {
-	r.Handle(http.MethodGet, path, handle)
+func (r *Router) GET(path string, handle Handle) *Route {
+	return r.Handle(http.MethodGet, path, handle)
 }
 
 // HEAD is a shortcut for router.Handle(http.MethodHead, path, handle)
-func (r *Router) HEAD(path string, handle Handle) {
-	r.Handle(http.MethodHead, path, handle)
+func (r *Router) HEAD(path string, handle Handle) *Route {
+	return r.Handle(http.MethodHead, path, handle)
 }
 
 // OPTIONS is a shortcut for router.Handle(http.MethodOptions, path, handle)
-func (r *Router) OPTIONS(path string, handle Handle) {
-	r.Handle(http.MethodOptions, path, handle)
+func (r *Router) OPTIONS(path string, handle Handle) *Route {
+	return r.Handle(http.MethodOptions, path, handle)
 }
 
 // POST is a shortcut for router.Handle(http.MethodPost, path, handle)
-func (r *Router) POST(path string, handle Handle) {
-	r.Handle(http.MethodPost, path, handle)
+func (r *Router) POST(path string, handle Handle) *Route {
+	return r.Handle(http.MethodPost, path, handle)
 }
 
 // PUT is a shortcut for router.Handle(http.MethodPut, path, handle)
-func (r *Router) PUT(path string, handle Handle) {
-	r.Handle(http.MethodPut, path, handle)
+func (r *Router) PUT(path string, handle Handle) *Route {
+	return r.Handle(http.MethodPut, path, handle)
 }
 
 // PATCH is a shortcut for router.Handle(http.MethodPatch, path, handle)
-func (r *Router) PATCH(path string, handle Handle) {
-	r.Handle(http.MethodPatch, path, handle)
+func (r *Router) PATCH(path string, handle Handle) *Route {
+	return r.Handle(http.MethodPatch, path, handle)
 }
 
 // DELETE is a shortcut for router.Handle(http.MethodDelete, path, handle)
-func (r *Router) DELETE(path string, handle Handle) {
-	r.Handle(http.MethodDelete, path, handle)
+func (r *Router) DELETE(path string, handle Handle) *Route {
+	return r.Handle(http.MethodDelete, path, handle)
 }
 
 // Handle registers a new request handle with the given path and method.
@@ -300,7 +471,10 @@ func (r *Router) DELETE(path string, handle Handle) {
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
-func (r *Router) Handle(method, path string, handle Handle) {
+//
+// A HandleE is registered via its Handle method. The returned route can be
+// named and given metadata, see Route.
+func (r *Router) Handle(method, path string, handle Handle) *Route {
 	varsCount := uint16(0)
 
 	if method == "" {
@@ -330,7 +504,10 @@ func (r *Router) Handle(method, path string, handle Handle) {
 		r.globalAllowed = r.allowed("*", "")
 	}
 
-	root.addRoute(path, handle)
+	route := root.addRoute(path, handle)
+	route.Method = method
+	route.router = r
+	r.routes = append(r.routes, route)
 
 	// Update maxParams
 	if paramsCount := countParams(path); paramsCount+varsCount > r.maxParams {
@@ -344,6 +521,8 @@ func (r *Router) Handle(method, path string, handle Handle) {
 			return &ps
 		}
 	}
+
+	return route
 }
 
 // ServeFiles serves files from the given file system root.
@@ -383,7 +562,7 @@ func (r *Router) recv(w http.ResponseWriter, req *http.Request) {
 // the same path with an extra / without the trailing slash should be performed.
 func (r *Router) Lookup(method, path string) (Handle, Params, bool) {
 	if root := r.trees[method]; root != nil {
-		handle, ps, tsr := root.getValue(path, r.getParams)
+		handle, ps, _, tsr := root.getValue(path, r.getParams)
 		if handle == nil {
 			r.putParams(ps)
 			return nil, nil, tsr
@@ -396,6 +575,73 @@ func (r *Router) Lookup(method, path string) (Handle, Params, bool) {
 	return nil, nil, false
 }
 
+// URL returns the path of the given route with its parameters replaced by the
+// given values in order, formatted with fmt.Sprint and escaped. For example
+//  router.URL("/users/:id/files/*filepath", 42, "/docs/a b.txt")
+// returns "/users/42/files/docs/a%20b.txt".
+// Instead of its path, a named route (see Route.WithName) can be given by name.
+// URL panics if the number of values doesn't match the number of parameters
+// or if there is no route with the given name.
+func (r *Router) URL(route string, values ...interface{}) string {
+	path, err := r.buildURL(route, values)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// buildURL returns the path of the given route, given by path or by name, with
+// its parameters replaced by the given values, see URL. The router may be nil,
+// in which case routes can't be given by name.
+func (r *Router) buildURL(route string, values []interface{}) (string, error) {
+	if route != "" && route[0] != '/' {
+		var rt *Route
+		if r != nil {
+			rt = r.Route(route)
+		}
+		if rt == nil {
+			return "", fmt.Errorf("no route named '%s'", route)
+		}
+		route = rt.Path
+	}
+	return buildURL(route, values)
+}
+
+// buildURL returns the path of the given route with its parameters replaced by
+// the given values, see Router.URL.
+func buildURL(route string, values []interface{}) (string, error) {
+	var buf strings.Builder
+	n := 0
+	for i := 0; i < len(route); i++ {
+		if route[i] != ':' && route[i] != '*' {
+			buf.WriteByte(route[i])
+			continue
+		}
+		if n == len(values) {
+			return "", fmt.Errorf("missing value for parameter %d of route '%s'", n+1, route)
+		}
+		value := fmt.Sprint(values[n])
+		if route[i] == '*' {
+			segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j := range segments {
+				segments[j] = url.PathEscape(segments[j])
+			}
+			value = strings.Join(segments, "/")
+		} else {
+			value = url.PathEscape(value)
+		}
+		buf.WriteString(value)
+		n++
+		for i+1 < len(route) && route[i+1] != '/' {
+			i++
+		}
+	}
+	if n != len(values) {
+		return "", fmt.Errorf("too many values for route '%s'", route)
+	}
+	return buf.String(), nil
+}
+
 func (r *Router) allowed(path, reqMethod string) (allow string) {
 	allowed := make([]string, 0, 9)
 
@@ -419,7 +665,7 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 				continue
 			}
 
-			handle, _, _ := r.trees[method].getValue(path, nil)
+			handle, _, _, _ := r.trees[method].getValue(path, nil)
 			if handle != nil {
 				// Add request method to list of allowed methods
 				allowed = append(allowed, method)
@@ -450,11 +696,15 @@ func (r *Router) allowed(path, reqMethod string) (allow string) {
 // ServeHTTP makes the router implement the http.Handler interface.
 func (r *Router) ServeHTTP(wo http.ResponseWriter, req *http.Request) {
 
+	// acquire a context object
+	c := AcquireContextObject()
+	defer ReleaseContextObject(c)
+
 	// wrap the original response writer into a negroni like response writer and go
 	// with that
 	//
 	// see: https://github.com/urfave/negroni/blob/master/response_writer.go
- 	w := NewResponseWriter(wo)
+	w := c.wrapResponseWriter(wo)
 
 	// if panics should be handled, "register" the handling
 	if r.PanicHandler != nil {
@@ -463,53 +713,46 @@ func (r *Router) ServeHTTP(wo http.ResponseWriter, req *http.Request) {
 
 	path := req.URL.Path
 
-	// if there is paths registered for the method (incl. OPTIONS)
-	if root := r.trees[req.Method]; root != nil {
+	// wrap router, request and response in the context object
+	c.router = r
+	c.Request = req
+	c.Response = w
+	c.OriginalPath = path
 
-		// try to match a registered handler
-		handle, ps, tsr := root.getValue(path, r.getParams)
-
-		// if there is a handler registered for this path (this is the "happy path")
-		if handle != nil {
-
-			// if parameters where extracted from the path
-			if ps != nil {
-
-				// acquire a context object
-				c := AcquireContextObject()
-
-				// wrap request, response and parameters in the context object
-				c.Request = req
-				c.Response = w
-				c.Params = *ps
-
-				// handle the request
-				handle(c)
+	// if there are normalizers, run the path through them
+	if len(r.PathNormalizers) > 0 {
+		for _, normalize := range r.PathNormalizers {
+			path = normalize(path)
+		}
 
-				// release the context object
-				ReleaseContextObject(c)
+		// if the path was changed, redirect or rewrite the request (unless CONNECT)
+		if path != c.OriginalPath && req.Method != http.MethodConnect {
+			if r.RedirectNormalizedPath {
+				code := redirectCode(req.Method)
+				redirect(w, req, path, "", code)
 
-				// release the parameters
-				r.putParams(ps)
+				r.initLogger(c)
+				c.Logger.Info().Int("status", code).Msg("")
 
-			} else {
+				// done serving the request
+				return
+			}
+			req.URL.Path = path
+			req.URL.RawPath = ""
+		}
+	}
 
-				// acquire a context object
-				c := AcquireContextObject()
+	// if there is paths registered for the method (incl. OPTIONS)
+	if root := r.trees[req.Method]; root != nil {
 
-				// wrap request and response in the context object
-				c.Request = req
-				c.Response = w
+		// try to match a registered handler
+		handle, ps, route, tsr := root.getValue(path, r.getParams)
 
-				// handle the request
-				handle(c)
-				// release the context object
-				ReleaseContextObject(c)
-			}
+		// if there is a handler registered for this path (this is the "happy path")
+		if handle != nil {
+			r.dispatch(c, handle, ps, path, route)
 
 			// done serving the request
-			//log.Info().Str("method", req.Method).Int("status", w.Status()).Msg(w.Error().Error())
-			log.Info().Str("method", req.Method).Int("status", w.Status()).Msg("")
 			return
 		}
 
@@ -518,45 +761,74 @@ func (r *Router) ServeHTTP(wo http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodConnect && path != "/" {
 
 			// set status 301 for GETs and 308 for all other methods
-			code := http.StatusMovedPermanently
-			if req.Method != http.MethodGet {
-				code = http.StatusPermanentRedirect
-			}
+			code := redirectCode(req.Method)
+
+			// determine the trailing slash policy applying to the path
+			policy := r.trailingSlashPolicy(path)
+
+			// if there is a trailing slash recommendation, apply the policy
+			if tsr && policy != TrailingSlashStrict {
+				tsrPath := toggleTrailingSlash(path)
 
-			// if there is a trailing slash recommendation and RedirectTrailingSlash is set,
-			// redirect there
-			if tsr && r.RedirectTrailingSlash {
-				if len(path) > 1 && path[len(path)-1] == '/' {
-					req.URL.Path = path[:len(path)-1]
+				// if TrailingSlashTolerant is set, serve the request with the
+				// handle registered for the other variant
+				if policy == TrailingSlashTolerant {
+					if handle, ps, route, _ := root.getValue(tsrPath, r.getParams); handle != nil {
+						r.dispatch(c, handle, ps, tsrPath, route)
+
+						// done serving the request
+						return
+					}
 				} else {
-					req.URL.Path = path + "/"
-				}
 
-				// redirect to the tsr-fixed URL
-				http.Redirect(w, req, req.URL.String(), code)
+					// a configured status code takes precedence
+					if r.TrailingSlashRedirectCode != 0 {
+						code = r.TrailingSlashRedirectCode
+					}
 
-				log.Info().Str("method", req.Method).Int("status", code).Msg("")
+					// keep the raw path in sync to preserve its encoding
+					rawPath := req.URL.RawPath
+					if rawPath != "" {
+						rawPath = toggleTrailingSlash(rawPath)
+					}
 
-				// done serving the request
-				return
+					// redirect to the tsr-fixed URL
+					redirect(w, req, tsrPath, rawPath, code)
+
+					r.initLogger(c)
+					c.Logger.Info().Int("status", code).Msg("")
+
+					// done serving the request
+					return
+				}
 			}
 
-			// if RedirectFixedPath is set, try to fix the and redirect case-errors
-			if r.RedirectFixedPath {
+			// if RedirectFixedPath or ServeFixedPath is set, try to fix case-errors
+			if r.RedirectFixedPath || r.ServeFixedPath {
 
 				// do a case insensitive path lookup
 				fixedPath, found := root.findCaseInsensitivePath(
 					CleanPath(path),
-					r.RedirectTrailingSlash,
+					policy != TrailingSlashStrict,
 				)
 
+				// if a path could be found through case insensitive lookup and
+				// ServeFixedPath is set, serve the request right away
+				if found && r.ServeFixedPath {
+					if handle, ps, route, _ := root.getValue(fixedPath, r.getParams); handle != nil {
+						r.dispatch(c, handle, ps, fixedPath, route)
+
+						// done serving the request
+						return
+					}
+				}
+
 				// if a path could be found through case insensitive lookup, redirect to the
 				// correct path
 				if found {
-					req.URL.Path = fixedPath
 
 					// redirect to the case-fixed URL
-					http.Redirect(w, req, req.URL.String(), code)
+					redirect(w, req, fixedPath, "", code)
 
 					// done serving the request
 					return
@@ -605,7 +877,7 @@ func (r *Router) ServeHTTP(wo http.ResponseWriter, req *http.Request) {
 				} else {
 
 					// call the default function (feeding the list of allowed method)
-					defaultMethodNotAllowed(w, req, allow)
+					defaultMethodNotAllowed(w, req, allow, r.ProblemDetails)
 				}
 
 				// done serving the request
@@ -624,29 +896,153 @@ func (r *Router) ServeHTTP(wo http.ResponseWriter, req *http.Request) {
 	} else {
 
 		// call the default callback
-		defaultNotFound(w, req)
+		defaultNotFound(w, req, r.ProblemDetails)
 	}
 }
 
-func defaultNotFound(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
-	w.WriteHeader(http.StatusNotFound)
-	bytes, _ := json.Marshal(struct {
-		Error string `json:"error"`
-	}{Error: http.StatusText(http.StatusNotFound)})
-	w.Write(bytes)
+// dispatch hands the context object to the given handle, after adding the
+// parameters (if any) and the per-request logger to it. The path is the
+// (possibly corrected) path the handle was looked up with, the route is the path
+// the handle was registered with.
+func (r *Router) dispatch(c *Context, handle Handle, ps *Params, path string, route *Route) {
+
+	// wrap the path, the route and the parameters (if any) in the context object
+	c.CanonicalPath = path
+	c.Route = route
+	if ps != nil {
+		c.Params = *ps
+	}
+
+	// derive the per-request logger and set the error handler
+	r.initLogger(c)
+	c.ErrorHandler = r.ErrorHandler
+
+	// handle the request
+	handle(c)
+
+	// release the parameters
+	r.putParams(ps)
+
+	c.Logger.Info().Int("status", c.Response.Status()).Msg("")
+}
+
+// initLogger sets the per-request logger of the context object, derived from
+// Router.Logger and enriched with the request metadata. The metadata is copied
+// into the logger, such that it stays valid if the logger is retained after
+// the context object was released.
+func (r *Router) initLogger(c *Context) {
+	logger := log.Logger
+	if r.Logger != nil {
+		logger = *r.Logger
+	}
+
+	// don't bother (and allocate) if logging is disabled anyway
+	if logger.GetLevel() == zerolog.Disabled || zerolog.GlobalLevel() == zerolog.Disabled {
+		c.Logger = logger
+		return
+	}
+
+	lc := logger.With().
+		Str("request_id", c.RequestID()).
+		Str("method", c.Request.Method).
+		Str("remote_ip", c.RealIP())
+	if c.Route != nil {
+		lc = lc.Str("route", c.Route.Path)
+	}
+	for _, fields := range r.LoggerFields {
+		lc = fields(c, lc)
+	}
+	c.Logger = lc.Logger()
+}
+
+// trailingSlashPolicy returns the trailing slash policy applying to the given
+// path, i.e. the policy of the group with the longest prefix matching the path
+// or the router's policy if there is no such group.
+func (r *Router) trailingSlashPolicy(path string) TrailingSlashPolicy {
+	policy, longest := r.TrailingSlash, -1
+	if policy == TrailingSlashRedirect && !r.RedirectTrailingSlash {
+		policy = TrailingSlashStrict
+	}
+	for prefix, p := range r.trailingSlash {
+		if len(prefix) > longest && matchPrefix(prefix, path) {
+			policy, longest = p, len(prefix)
+		}
+	}
+	return policy
 }
 
-func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request, allow string) {
+// redirectCode returns the status code used for redirects fixing the request
+// path: 301 for GET requests and 308 for all other request methods.
+func redirectCode(method string) int {
+	if method != http.MethodGet {
+		return http.StatusPermanentRedirect
+	}
+	return http.StatusMovedPermanently
+}
+
+// toggleTrailingSlash removes the trailing slash from the given path or adds
+// one, if there is none.
+func toggleTrailingSlash(path string) string {
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		return path[:len(path)-1]
+	}
+	return path + "/"
+}
+
+// redirect replies to the request with a redirect to the given path, keeping
+// the query string of the original request URL. The raw path may be empty.
+func redirect(w http.ResponseWriter, req *http.Request, path, rawPath string, code int) {
+	u := *req.URL
+	u.Path = path
+	u.RawPath = rawPath
+	http.Redirect(w, req, u.String(), code)
+}
+
+func defaultNotFound(w http.ResponseWriter, r *http.Request, problem bool) {
+	writeDefault(w, r, http.StatusNotFound, problem)
+}
+
+func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request, allow string, problem bool) {
 
 	// see: https://tools.ietf.org/html/rfc7231#section-6.5.5
 
 	w.Header().Set("Allow", allow)
+	writeDefault(w, r, http.StatusMethodNotAllowed, problem)
+}
+
+// DefaultPanicHandler logs the recovered value and answers the request with
+// status code 500 (Internal Server Error), rendered as problem details if
+// ProblemDetails is set. It can be used as PanicHandler:
+//  router.PanicHandler = router.DefaultPanicHandler
+func (r *Router) DefaultPanicHandler(w http.ResponseWriter, req *http.Request, rcv interface{}) {
+	logger := log.Logger
+	if r.Logger != nil {
+		logger = *r.Logger
+	}
+	logger.Error().Str("method", req.Method).Str("path", req.URL.Path).
+		Interface("panic", rcv).Msg("recovered from panic")
+
+	// only answer, if the handler didn't already
+	if rw, ok := w.(ResponseWriter); ok && rw.Written() {
+		return
+	}
+	writeDefault(w, req, http.StatusInternalServerError, r.ProblemDetails)
+}
+
+// writeDefault writes the default response for the given status code, i.e. a
+// JSON object with the status text as "error" member or problem details.
+func writeDefault(w http.ResponseWriter, r *http.Request, status int, problem bool) {
+	if problem {
+		p := NewProblem(status, "")
+		p.Instance = r.URL.Path
+		writeProblem(w, p)
+		return
+	}
 	w.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
-	w.WriteHeader(http.StatusMethodNotAllowed)
+	w.WriteHeader(status)
 	bytes, _ := json.Marshal(struct {
 		Error string `json:"error"`
-	}{Error: http.StatusText(http.StatusMethodNotAllowed)})
+	}{Error: http.StatusText(status)})
 	w.Write(bytes)
 }
 
@@ -675,6 +1071,7 @@ func defaultOptions(w http.ResponseWriter, r *http.Request, allow string) {
 const (
 	MIMEApplicationJSON                  = "application/json"
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + "; " + charsetUTF8
+	MIMEApplicationProblemJSON           = "application/problem+json"
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + charsetUTF8
 	MIMEApplicationXML                   = "application/xml"
@@ -688,6 +1085,7 @@ const (
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + charsetUTF8
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
+	MIMETextEventStream                  = "text/event-stream"
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
 )
@@ -706,6 +1104,7 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
@@ -713,6 +1112,7 @@ const (
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
 	HeaderUpgrade             = "Upgrade"
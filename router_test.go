@@ -5,12 +5,16 @@
 package httprouter
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+
+	"github.com/rs/zerolog"
 )
 
 type mockResponseWriter struct{}
@@ -182,6 +186,69 @@ func TestRouterInvalidInput(t *testing.T) {
 	}
 }
 
+func TestRouterHandleE(t *testing.T) {
+	logger := zerolog.Nop()
+	router := New()
+	router.Logger = &logger
+	router.GET("/ok", HandleE(func(c *Context) error {
+		c.NoContent(http.StatusNoContent)
+		return nil
+	}).Handle())
+	router.GET("/bad", HandleE(func(_ *Context) error {
+		return NewHTTPError(http.StatusBadRequest, errors.New("bad input"))
+	}).Handle())
+	router.GET("/wrapped", HandleE(func(_ *Context) error {
+		return fmt.Errorf("wrapped: %w", NewHTTPError(http.StatusConflict, nil))
+	}).Handle())
+	router.GET("/internal", HandleE(func(_ *Context) error {
+		return errors.New("secret")
+	}).Handle())
+	router.GET("/written", HandleE(func(c *Context) error {
+		c.NoContent(http.StatusAccepted)
+		return errors.New("too late")
+	}).Handle())
+
+	testRoutes := []struct {
+		route string
+		code  int
+		body  string
+	}{
+		{"/ok", http.StatusNoContent, ""},
+		{"/bad", http.StatusBadRequest, `{"error":"bad input"}`},
+		{"/wrapped", http.StatusConflict, `{"error":"wrapped: Conflict"}`},
+		{"/internal", http.StatusInternalServerError, `{"error":"Internal Server Error"}`},
+		{"/written", http.StatusAccepted, ""},
+	}
+	for _, tr := range testRoutes {
+		r, _ := http.NewRequest(http.MethodGet, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tr.code || w.Body.String() != tr.body {
+			t.Errorf("route %s: want %d %q, got %d %q", tr.route, tr.code, tr.body, w.Code, w.Body.String())
+		}
+	}
+
+	// test custom error handler
+	var handled error
+	router.ErrorHandler = func(status int, err error, c *Context) {
+		handled = err
+		c.NoContent(status)
+	}
+	r, _ := http.NewRequest(http.MethodGet, "/internal", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError || handled == nil || handled.Error() != "secret" {
+		t.Errorf("custom error handler failed: Code=%d, err=%v", w.Code, handled)
+	}
+
+	recv := catchPanic(func() {
+		router.GET("/nil", HandleE(nil).Handle())
+	})
+	if recv == nil {
+		t.Fatal("registering nil HandleE did not panic")
+	}
+}
+
 func BenchmarkAllowed(b *testing.B) {
 	handlerFunc := func(_ *Context) {}
 
@@ -203,6 +270,184 @@ func BenchmarkAllowed(b *testing.B) {
 	})
 }
 
+func TestRouterMallocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping malloc count in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping malloc count with race detector enabled")
+	}
+
+	handlerFunc := func(_ *Context) {}
+
+	// enriching the per-request logger allocates, unless logging is disabled
+	logger := zerolog.Nop()
+	router := New()
+	router.Logger = &logger
+	router.GET("/static", handlerFunc)
+	router.GET("/user/:name", handlerFunc)
+
+	w := new(mockResponseWriter)
+	for _, route := range []string{"/static", "/user/gopher"} {
+		r, _ := http.NewRequest(http.MethodGet, route, nil)
+		allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, r) })
+		if allocs > 0 {
+			t.Errorf("serving %s: %v allocs, want zero", route, allocs)
+		}
+	}
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	logger := zerolog.Nop()
+	router := New()
+	router.Logger = &logger
+	router.GET("/static", func(_ *Context) {})
+
+	w := new(mockResponseWriter)
+	r, _ := http.NewRequest(http.MethodGet, "/static", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkRouterParam(b *testing.B) {
+	logger := zerolog.Nop()
+	router := New()
+	router.Logger = &logger
+	router.GET("/user/:name", func(_ *Context) {})
+
+	w := new(mockResponseWriter)
+	r, _ := http.NewRequest(http.MethodGet, "/user/gopher", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, r)
+	}
+}
+
+func TestRouterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	router := New()
+	router.Logger = &logger
+	router.LoggerFields = []LoggerFieldsFunc{
+		func(c *Context, l zerolog.Context) zerolog.Context {
+			return l.Str("tenant", c.Request.Header.Get("X-Tenant"))
+		},
+	}
+	router.GET("/user/:name", func(c *Context) {
+		c.Logger.Info().Msg("hello")
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/user/gopher", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set(HeaderXRequestID, "42")
+	r.Header.Set("X-Tenant", "acme")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	dec := json.NewDecoder(&buf)
+	for _, msg := range []string{"hello", ""} {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding log entry failed: %v", err)
+		}
+		want := map[string]interface{}{
+			"request_id": "42",
+			"method":     http.MethodGet,
+			"route":      "/user/:name",
+			"remote_ip":  "192.0.2.1",
+			"tenant":     "acme",
+		}
+		if msg != "" {
+			want["message"] = msg
+		}
+		for key, value := range want {
+			if entry[key] != value {
+				t.Errorf("wrong log field %s: want %v, got %v", key, value, entry[key])
+			}
+		}
+	}
+}
+
+func TestRouterLoggerGeneratedRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	router := New()
+	router.Logger = &logger
+	var id string
+	router.GET("/", func(c *Context) {
+		c.Logger.Info().Msg("hello")
+		id = c.RequestID()
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var entry map[string]interface{}
+	if err := json.NewDecoder(&buf).Decode(&entry); err != nil {
+		t.Fatalf("decoding log entry failed: %v", err)
+	}
+	if len(id) != 32 || entry["request_id"] != id {
+		t.Errorf("logged request ID %v doesn't match %q", entry["request_id"], id)
+	}
+
+	// a new logger applies to the next request
+	var buf2 bytes.Buffer
+	logger2 := zerolog.New(&buf2)
+	router.Logger = &logger2
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if buf2.Len() == 0 {
+		t.Error("new logger not used")
+	}
+}
+
+func TestRouterLoggerRetained(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(zerolog.SyncWriter(&buf))
+
+	router := New()
+	router.Logger = &logger
+	retained := make(chan zerolog.Logger, 1)
+	router.GET("/first", func(c *Context) {
+		retained <- c.Logger
+	})
+	router.GET("/second", func(c *Context) {})
+
+	r, _ := http.NewRequest(http.MethodGet, "/first", nil)
+	r.Header.Set(HeaderXRequestID, "1")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// log with the logger of the first request while serving the second one
+	done := make(chan struct{})
+	go func() {
+		l := <-retained
+		l.Info().Msg("retained")
+		close(done)
+	}()
+	r, _ = http.NewRequest(http.MethodGet, "/second", nil)
+	r.Header.Set(HeaderXRequestID, "2")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	<-done
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding log entry failed: %v", err)
+		}
+		if entry["message"] != "retained" {
+			continue
+		}
+		if entry["request_id"] != "1" || entry["route"] != "/first" {
+			t.Errorf("retained logger logged wrong request: %v", entry)
+		}
+		return
+	}
+	t.Error("retained logger didn't log")
+}
+
 func TestRouterOPTIONS(t *testing.T) {
 	handlerFunc := func(_ *Context) {}
 
@@ -417,6 +662,199 @@ func TestRouterNotFound(t *testing.T) {
 	}
 }
 
+func TestRouterServeFixedPath(t *testing.T) {
+	var canonical, name string
+	handlerFunc := func(c *Context) {
+		canonical = c.CanonicalPath
+		name = c.Params.ByName("name")
+	}
+
+	router := New()
+	router.ServeFixedPath = true
+	router.GET("/path", handlerFunc)
+	router.POST("/user/:name", handlerFunc)
+
+	testRoutes := []struct {
+		method    string
+		route     string
+		canonical string
+		name      string
+	}{
+		{http.MethodGet, "/path", "/path", ""},
+		{http.MethodGet, "/PATH", "/path", ""},
+		{http.MethodGet, "/../PaTh/", "/path", ""},
+		{http.MethodPost, "/USER/GoPher", "/user/GoPher", "GoPher"},
+	}
+	for _, tr := range testRoutes {
+		canonical, name = "", ""
+		r, _ := http.NewRequest(tr.method, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("ServeFixedPath handling route %s failed: Code=%d", tr.route, w.Code)
+		}
+		if canonical != tr.canonical {
+			t.Errorf("wrong canonical path for route %s: want %s, got %s", tr.route, tr.canonical, canonical)
+		}
+		if name != tr.name {
+			t.Errorf("wrong param value for route %s: want %s, got %s", tr.route, tr.name, name)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/nope", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("ServeFixedPath handling route /nope failed: Code=%d", w.Code)
+	}
+}
+
+func TestRouterTrailingSlash(t *testing.T) {
+	var served string
+	handlerFunc := func(c *Context) {
+		served = c.CanonicalPath
+	}
+
+	router := New()
+	router.RedirectFixedPath = false
+	router.GET("/path", handlerFunc)
+	router.GET("/dir/", handlerFunc)
+	router.GET("/files/:name", handlerFunc)
+	api := router.Group("/api")
+	api.GET("/path", handlerFunc)
+	users := api.Group("/users/:id")
+	users.GET("/dir/", handlerFunc)
+	users.SetTrailingSlash(TrailingSlashTolerant)
+	api.Group("/strict").SetTrailingSlash(TrailingSlashStrict)
+	api.Group("/strict").GET("/path", handlerFunc)
+
+	testRoutes := []struct {
+		policy   TrailingSlashPolicy
+		code     int
+		route    string
+		location string
+		served   string
+	}{
+		{TrailingSlashStrict, http.StatusNotFound, "/path/", "", ""},
+		{TrailingSlashStrict, http.StatusNotFound, "/dir", "", ""},
+		{TrailingSlashStrict, http.StatusOK, "/api/users/1/dir", "", "/api/users/1/dir/"},
+		{TrailingSlashRedirect, http.StatusMovedPermanently, "/path/?a=b", "/path?a=b", ""},
+		{TrailingSlashRedirect, http.StatusMovedPermanently, "/dir?a=b", "/dir/?a=b", ""},
+		{TrailingSlashRedirect, http.StatusMovedPermanently, "/files/%41/", "/files/%41", ""},
+		{TrailingSlashRedirect, http.StatusMovedPermanently, "/api/path/", "/api/path", ""},
+		{TrailingSlashRedirect, http.StatusNotFound, "/api/strict/path/", "", ""},
+		{TrailingSlashTolerant, http.StatusOK, "/path/", "", "/path"},
+		{TrailingSlashTolerant, http.StatusOK, "/dir", "", "/dir/"},
+		{TrailingSlashTolerant, http.StatusOK, "/api/users/1/dir", "", "/api/users/1/dir/"},
+		{TrailingSlashTolerant, http.StatusNotFound, "/api/strict/path/", "", ""},
+	}
+	for _, tr := range testRoutes {
+		served = ""
+		router.TrailingSlash = tr.policy
+		r, _ := http.NewRequest(http.MethodGet, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tr.code || w.Header().Get("Location") != tr.location {
+			t.Errorf("trailing slash policy %d, route %s failed: Code=%d, Location=%s", tr.policy, tr.route, w.Code, w.Header().Get("Location"))
+		}
+		if served != tr.served {
+			t.Errorf("trailing slash policy %d, route %s: served %q, want %q", tr.policy, tr.route, served, tr.served)
+		}
+	}
+
+	// test configured redirect code
+	router.TrailingSlash = TrailingSlashRedirect
+	router.TrailingSlashRedirectCode = http.StatusFound
+	r, _ := http.NewRequest(http.MethodPost, "/path/", nil)
+	router.POST("/path", handlerFunc)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusFound {
+		t.Errorf("unexpected redirect code %d want %d", w.Code, http.StatusFound)
+	}
+
+	// the deprecated RedirectTrailingSlash disables redirects of the router
+	router.RedirectTrailingSlash = false
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("redirected despite RedirectTrailingSlash disabled: %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/1/dir", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("group policy not applied: %d", w.Code)
+	}
+}
+
+func TestRouterPathNormalizers(t *testing.T) {
+	var original, path, name string
+	handlerFunc := func(c *Context) {
+		original = c.OriginalPath
+		path = c.Request.URL.Path
+		name = c.Params.ByName("name")
+	}
+
+	router := New()
+	router.PathNormalizers = []PathNormalizer{CleanPath, TrimTrailingDots, LowercasePath}
+	router.GET("/user/:name", handlerFunc)
+
+	testRoutes := []struct {
+		route string
+		name  string
+	}{
+		{"/user/gopher", "gopher"},
+		{"/user//./gopher", "gopher"},
+		{"/USER/Gopher.", "gopher"},
+	}
+	for _, tr := range testRoutes {
+		original, path, name = "", "", ""
+		r, _ := http.NewRequest(http.MethodGet, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("normalizing route %s failed: Code=%d", tr.route, w.Code)
+		}
+		if original != tr.route || path != "/user/"+tr.name || name != tr.name {
+			t.Errorf("normalizing route %s failed: original=%s, path=%s, name=%s", tr.route, original, path, name)
+		}
+	}
+
+	router.RedirectNormalizedPath = true
+	r, _ := http.NewRequest(http.MethodGet, "/USER//Gopher?a=b", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/user/gopher?a=b" {
+		t.Errorf("redirecting normalized path failed: Code=%d, Location=%s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		path   string
+		match  bool
+	}{
+		{"", "/", true},
+		{"/api", "/api", true},
+		{"/api", "/api/", true},
+		{"/api", "/api/users", true},
+		{"/api", "/apis", false},
+		{"/api", "/ap", false},
+		{"/users/:id", "/users/1/dir", true},
+		{"/users/:id", "/users/1", true},
+		{"/users/:id", "/users/", false},
+		{"/users/:id/dir", "/users/1/dir", true},
+		{"/users/:id/dir", "/users/1/dirs", false},
+		{"/files/*path", "/files/a/b", true},
+	}
+	for _, test := range tests {
+		if match := matchPrefix(test.prefix, test.path); match != test.match {
+			t.Errorf("matchPrefix(%q, %q) = %v, want %v", test.prefix, test.path, match, test.match)
+		}
+	}
+}
+
 func TestRouterPanicHandler(t *testing.T) {
 	router := New()
 	panicHandled := false
@@ -0,0 +1,379 @@
+package httprouter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"sync"
+	"time"
+)
+
+// SessionStore persists the values of sessions, see Sessions.
+type SessionStore interface {
+	// Load returns the values of the session with the given ID, or nil if there
+	// is no such session (e.g. because it expired).
+	Load(c *Context, id string) (map[string]interface{}, error)
+	// Save stores the values of the session with the given ID, to expire after
+	// the given lifetime.
+	Save(c *Context, id string, values map[string]interface{}, maxAge time.Duration) error
+	// Delete deletes the session with the given ID.
+	Delete(c *Context, id string) error
+}
+
+// Sessions manages sessions identified by a session ID stored in a cookie
+// signed with a SecureCookie, the session values are persisted in a
+// SessionStore. Routes use sessions by being wrapped with Middleware and
+// calling Context.Session.
+//
+// Sessions are loaded on the first call of Context.Session and saved just
+// before the response is written if they were modified, thus routes which
+// never touch the session don't access the store.
+type Sessions struct {
+	// The store persisting the session values.
+	Store SessionStore
+	// The SecureCookie encoding the session cookie.
+	Cookie *SecureCookie
+	// The name of the session cookie.
+	// Default: "session"
+	Name string
+	// The lifetime of sessions, renewed whenever a session is saved.
+	// Default: 24 hours
+	MaxAge time.Duration
+}
+
+// NewSessions returns a new session manager with the given store and secure
+// cookie.
+func NewSessions(store SessionStore, cookie *SecureCookie) *Sessions {
+	return &Sessions{
+		Store:  store,
+		Cookie: cookie,
+		Name:   "session",
+		MaxAge: 24 * time.Hour,
+	}
+}
+
+// Middleware wraps the given handle such that it can use Context.Session.
+func (m *Sessions) Middleware(handle Handle) Handle {
+	if handle == nil {
+		panic("handle must not be nil")
+	}
+	return func(c *Context) {
+		c.sessions = m
+		handle(c)
+		// save sessions of handles which didn't write a response
+		if s := c.session; s != nil && !c.Response.Written() {
+			s.save()
+		}
+	}
+}
+
+// errNoSessions is the panic raised by Context.Session for handles not wrapped
+// by Sessions.Middleware.
+const errNoSessions = "session used without Sessions.Middleware"
+
+// Session returns the session of the request, loading it on first use. If the
+// request has no (valid) session cookie, a new session is started.
+// The handle must be wrapped with Sessions.Middleware.
+func (c *Context) Session() (*Session, error) {
+	if c.session != nil {
+		return c.session, nil
+	}
+	if c.sessions == nil {
+		panic(errNoSessions)
+	}
+	s, err := c.sessions.load(c)
+	if err != nil {
+		return nil, err
+	}
+	c.session = s
+	c.Response.Before(func(ResponseWriter) { s.save() })
+	return s, nil
+}
+
+// load loads the session of the request.
+func (m *Sessions) load(c *Context) (*Session, error) {
+	s := &Session{m: m, c: c}
+	if id, err := c.SecureCookie(m.Cookie, m.Name); err == nil {
+		values, err := m.Store.Load(c, string(id))
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			s.id, s.values = string(id), values
+			return s, nil
+		}
+	}
+	s.id = newSessionID()
+	s.values = make(map[string]interface{})
+	s.isNew = true
+	return s, nil
+}
+
+// newSessionID returns a new random session ID.
+func newSessionID() string {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// Session is a set of values persisted across the requests of a client.
+// Sessions are not safe for concurrent use.
+type Session struct {
+	m *Sessions
+	c *Context
+
+	id        string
+	values    map[string]interface{}
+	isNew     bool
+	modified  bool
+	destroyed bool
+	saved     bool
+	// IDs replaced by RenewID, deleted when saving
+	oldIDs []string
+}
+
+// ID returns the ID of the session.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// IsNew reports whether the session was started with this request.
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Get returns the value stored under the given key, nil if there is none.
+func (s *Session) Get(key string) interface{} {
+	return s.values[key]
+}
+
+// GetString returns the value stored under the given key if it is a string.
+func (s *Session) GetString(key string) string {
+	v, _ := s.values[key].(string)
+	return v
+}
+
+// GetInt returns the value stored under the given key if it is an int.
+func (s *Session) GetInt(key string) int {
+	v, _ := s.values[key].(int)
+	return v
+}
+
+// Set stores the given value under the given key.
+func (s *Session) Set(key string, value interface{}) {
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value stored under the given key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Keys returns the keys of all values of the session.
+func (s *Session) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// RenewID gives the session a new ID, keeping its values. The session should be
+// renewed whenever the privilege level changes (e.g. on login) to prevent
+// session fixation attacks.
+func (s *Session) RenewID() {
+	if !s.isNew {
+		s.oldIDs = append(s.oldIDs, s.id)
+	}
+	s.id = newSessionID()
+	s.modified = true
+}
+
+// Destroy deletes the session and its values, the session cookie is cleared.
+func (s *Session) Destroy() {
+	s.values = make(map[string]interface{})
+	s.destroyed = true
+}
+
+// save persists the session if it was modified or destroyed. Errors are logged
+// as the response can't be changed anymore.
+func (s *Session) save() {
+	if s.saved {
+		return
+	}
+	s.saved = true
+	if err := s.persist(); err != nil {
+		s.c.Logger.Error().Err(err).Msg("saving session failed")
+	}
+}
+
+func (s *Session) persist() error {
+	m, c := s.m, s.c
+	for _, id := range s.oldIDs {
+		if err := m.Store.Delete(c, id); err != nil {
+			return err
+		}
+	}
+	if s.destroyed {
+		if !s.isNew || len(s.oldIDs) > 0 {
+			c.ClearCookie(c.NewCookie(m.Name, ""))
+			return m.Store.Delete(c, s.id)
+		}
+		return nil
+	}
+	if !s.modified {
+		return nil
+	}
+	if err := m.Store.Save(c, s.id, s.values, m.MaxAge); err != nil {
+		return err
+	}
+	cookie := c.NewCookie(m.Name, "")
+	cookie.MaxAge = int(m.MaxAge / time.Second)
+	return c.SetSecureCookie(m.Cookie, cookie, []byte(s.id))
+}
+
+// MemoryStore is a SessionStore keeping sessions in memory. Expired sessions
+// are evicted when accessed and periodically when sessions are saved.
+type MemoryStore struct {
+	lock      sync.Mutex
+	sessions  map[string]memorySession
+	nextSweep time.Time
+}
+
+type memorySession struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// memorySweepInterval is the interval in which MemoryStore evicts expired
+// sessions.
+const memorySweepInterval = time.Minute
+
+// NewMemoryStore returns a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memorySession)}
+}
+
+// Load implements the SessionStore interface.
+func (ms *MemoryStore) Load(_ *Context, id string) (map[string]interface{}, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	s, ok := ms.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(s.expires) {
+		delete(ms.sessions, id)
+		return nil, nil
+	}
+	return copyValues(s.values), nil
+}
+
+// Save implements the SessionStore interface.
+func (ms *MemoryStore) Save(_ *Context, id string, values map[string]interface{}, maxAge time.Duration) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	now := time.Now()
+	if now.After(ms.nextSweep) {
+		for k, s := range ms.sessions {
+			if !now.Before(s.expires) {
+				delete(ms.sessions, k)
+			}
+		}
+		ms.nextSweep = now.Add(memorySweepInterval)
+	}
+	ms.sessions[id] = memorySession{values: copyValues(values), expires: now.Add(maxAge)}
+	return nil
+}
+
+// Delete implements the SessionStore interface.
+func (ms *MemoryStore) Delete(_ *Context, id string) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	delete(ms.sessions, id)
+	return nil
+}
+
+// Len returns the number of sessions in the store, including expired sessions
+// which weren't evicted yet.
+func (ms *MemoryStore) Len() int {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	return len(ms.sessions)
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
+
+// CookieStore is a SessionStore keeping the session values client side in a
+// cookie (named like the session cookie with suffix ".data") encoded with a
+// SecureCookie, which should encrypt the values. Values are encoded with
+// encoding/gob, custom types must be registered with gob.Register. As cookies
+// are limited in size, only few and small values can be stored.
+type CookieStore struct {
+	// The SecureCookie encoding the values.
+	Cookie *SecureCookie
+	// The name of the cookie holding the values.
+	// Default: "session.data"
+	Name string
+}
+
+// NewCookieStore returns a new CookieStore encoding values with the given
+// SecureCookie.
+func NewCookieStore(cookie *SecureCookie) *CookieStore {
+	return &CookieStore{Cookie: cookie, Name: "session.data"}
+}
+
+// cookieSession is the content of the cookie of a CookieStore.
+type cookieSession struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// Load implements the SessionStore interface.
+func (cs *CookieStore) Load(c *Context, id string) (map[string]interface{}, error) {
+	b, err := c.SecureCookie(cs.Cookie, cs.Name)
+	if err != nil {
+		return nil, nil
+	}
+	var s cookieSession
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(&s); err != nil || s.ID != id {
+		return nil, nil
+	}
+	if s.Values == nil {
+		s.Values = make(map[string]interface{})
+	}
+	return s.Values, nil
+}
+
+// Save implements the SessionStore interface.
+func (cs *CookieStore) Save(c *Context, id string, values map[string]interface{}, maxAge time.Duration) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cookieSession{ID: id, Values: values}); err != nil {
+		return err
+	}
+	cookie := c.NewCookie(cs.Name, "")
+	cookie.MaxAge = int(maxAge / time.Second)
+	return c.SetSecureCookie(cs.Cookie, cookie, buf.Bytes())
+}
+
+// Delete implements the SessionStore interface.
+func (cs *CookieStore) Delete(c *Context, id string) error {
+	// the cookie of the current session is replaced when saving
+	if s, err := cs.Load(c, id); err == nil && s != nil {
+		c.ClearCookie(c.NewCookie(cs.Name, ""))
+	}
+	return nil
+}
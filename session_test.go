@@ -0,0 +1,203 @@
+package httprouter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingStore counts the accesses of the wrapped store.
+type countingStore struct {
+	SessionStore
+	loads, saves, deletes int
+}
+
+func (cs *countingStore) Load(c *Context, id string) (map[string]interface{}, error) {
+	cs.loads++
+	return cs.SessionStore.Load(c, id)
+}
+
+func (cs *countingStore) Save(c *Context, id string, values map[string]interface{}, maxAge time.Duration) error {
+	cs.saves++
+	return cs.SessionStore.Save(c, id, values, maxAge)
+}
+
+func (cs *countingStore) Delete(c *Context, id string) error {
+	cs.deletes++
+	return cs.SessionStore.Delete(c, id)
+}
+
+// sessionClient keeps the cookies set by the router across requests.
+type sessionClient struct {
+	router  *Router
+	cookies map[string]*http.Cookie
+}
+
+func (sc *sessionClient) get(t *testing.T, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range sc.cookies {
+		req.AddCookie(cookie)
+	}
+	sc.router.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(sc.cookies, cookie.Name)
+		} else {
+			sc.cookies[cookie.Name] = cookie
+		}
+	}
+	return w
+}
+
+func sessionRouter(sessions *Sessions) *Router {
+	router := New()
+	router.GET("/untouched", sessions.Middleware(func(c *Context) {
+		_ = c.String(http.StatusOK, "untouched")
+	}))
+	router.GET("/set/:value", sessions.Middleware(HandleE(func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.Set("value", c.Params.ByName("value"))
+		s.Set("count", s.GetInt("count")+1)
+		return c.NoContent(http.StatusNoContent)
+	}).Handle()))
+	router.GET("/get", sessions.Middleware(HandleE(func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, s.GetString("value"))
+	}).Handle()))
+	router.GET("/login", sessions.Middleware(HandleE(func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.RenewID()
+		s.Set("user", "gopher")
+		return nil // saved without response being written
+	}).Handle()))
+	router.GET("/logout", sessions.Middleware(HandleE(func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.Destroy()
+		return c.NoContent(http.StatusNoContent)
+	}).Handle()))
+	return router
+}
+
+func TestSessionsMemoryStore(t *testing.T) {
+	memory := NewMemoryStore()
+	store := &countingStore{SessionStore: memory}
+	sessions := NewSessions(store, NewSecureCookie(bytes.Repeat([]byte{1}, 32)))
+	client := &sessionClient{router: sessionRouter(sessions), cookies: map[string]*http.Cookie{}}
+
+	// routes not touching the session cost nothing
+	if w := client.get(t, "/untouched"); len(w.Result().Cookies()) != 0 || store.loads+store.saves != 0 {
+		t.Errorf("untouched session accessed: %d cookies, %d loads, %d saves", len(w.Result().Cookies()), store.loads, store.saves)
+	}
+	// reading a new session doesn't create it
+	if w := client.get(t, "/get"); w.Body.String() != "" || len(client.cookies) != 0 || memory.Len() != 0 {
+		t.Errorf("new session created on read")
+	}
+
+	client.get(t, "/set/a")
+	cookie := client.cookies["session"]
+	if cookie == nil || !cookie.HttpOnly || cookie.MaxAge != 86400 {
+		t.Fatalf("wrong session cookie: %v", cookie)
+	}
+	if w := client.get(t, "/get"); w.Body.String() != "a" {
+		t.Errorf("wrong session value: want %q, got %q", "a", w.Body.String())
+	}
+	client.get(t, "/set/b")
+	if w := client.get(t, "/get"); w.Body.String() != "b" {
+		t.Errorf("wrong session value: want %q, got %q", "b", w.Body.String())
+	}
+
+	// the ID is rotated on login, the old session deleted
+	var oldID string
+	for id := range memory.sessions {
+		oldID = id
+	}
+	client.get(t, "/login")
+	if _, ok := memory.sessions[oldID]; ok || memory.Len() != 1 {
+		t.Errorf("old session not deleted after renewing the ID")
+	}
+	for _, s := range memory.sessions {
+		if s.values["user"] != "gopher" || s.values["value"] != "b" || s.values["count"] != 2 {
+			t.Errorf("wrong values after renewing the ID: %v", s.values)
+		}
+	}
+	if w := client.get(t, "/get"); w.Body.String() != "b" {
+		t.Errorf("wrong session value after renewing the ID: %q", w.Body.String())
+	}
+
+	// logout destroys the session
+	client.get(t, "/logout")
+	if memory.Len() != 0 || len(client.cookies) != 0 {
+		t.Errorf("session not destroyed: %d sessions, cookies %v", memory.Len(), client.cookies)
+	}
+
+	// sessions with invalid cookies are new
+	client.cookies["session"] = &http.Cookie{Name: "session", Value: "forged"}
+	if w := client.get(t, "/get"); w.Body.String() != "" {
+		t.Errorf("forged session accepted")
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.Save(nil, "expired", map[string]interface{}{"a": 1}, -time.Second)
+	_ = store.Save(nil, "valid", map[string]interface{}{"a": 1}, time.Hour)
+	if values, _ := store.Load(nil, "expired"); values != nil {
+		t.Error("expired session loaded")
+	}
+	if values, _ := store.Load(nil, "valid"); values["a"] != 1 {
+		t.Errorf("wrong values: %v", values)
+	}
+
+	// expired sessions are swept when saving
+	_ = store.Save(nil, "expired", nil, -time.Second)
+	store.nextSweep = time.Time{}
+	_ = store.Save(nil, "other", nil, time.Hour)
+	if store.Len() != 2 {
+		t.Errorf("expired session not swept: %d sessions", store.Len())
+	}
+}
+
+func TestSessionsCookieStore(t *testing.T) {
+	sc := NewSecureCookie(bytes.Repeat([]byte{1}, 32))
+	sc.Encrypt = true
+	sessions := NewSessions(NewCookieStore(sc), sc)
+	client := &sessionClient{router: sessionRouter(sessions), cookies: map[string]*http.Cookie{}}
+
+	client.get(t, "/set/a")
+	if client.cookies["session"] == nil || client.cookies["session.data"] == nil {
+		t.Fatalf("cookies not set: %v", client.cookies)
+	}
+	if w := client.get(t, "/get"); w.Body.String() != "a" {
+		t.Errorf("wrong session value: want %q, got %q", "a", w.Body.String())
+	}
+	client.get(t, "/login")
+	if w := client.get(t, "/get"); w.Body.String() != "a" {
+		t.Errorf("wrong session value after renewing the ID: %q", w.Body.String())
+	}
+	client.get(t, "/logout")
+	if len(client.cookies) != 0 {
+		t.Errorf("cookies not cleared: %v", client.cookies)
+	}
+}
+
+func TestContextSessionWithoutMiddleware(t *testing.T) {
+	c := &Context{}
+	if recv := catchPanic(func() { _, _ = c.Session() }); recv != errNoSessions {
+		t.Errorf("wrong panic: %v", recv)
+	}
+}
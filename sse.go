@@ -0,0 +1,214 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSSEKeepAlive is the default interval of keep-alive comments of event
+// streams, see Router.SSEKeepAlive.
+const defaultSSEKeepAlive = 15 * time.Second
+
+// errInvalidEventField is returned by EventStream.Send for event names and IDs
+// containing line breaks (or NUL for IDs), which can't be encoded.
+var errInvalidEventField = errors.New("event name or ID contains a line break")
+
+// EventStream writes server-sent events (see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html) to the
+// response, see Context.SSE.
+// The methods of an EventStream are safe for concurrent use. Once the stream is
+// closed or the request is cancelled (e.g. because the client went away), all
+// methods return an error.
+type EventStream struct {
+	c           *Context
+	lastEventID string
+
+	lock      sync.Mutex
+	err       error
+	closed    chan struct{}
+	closeOnce sync.Once
+	stopped   sync.WaitGroup
+}
+
+// SSE starts a stream of server-sent events: the response is written with the
+// status code 200 and content type text/event-stream, afterwards events are
+// written with the returned EventStream. While the stream is open, keep-alive
+// comments are sent periodically (see Router.SSEKeepAlive). The stream is
+// closed when the request is cancelled or the handler returns, the handler must
+// not write to the response in any other way.
+//
+//	router.GET("/events", func(c *Context) {
+//		stream := c.SSE()
+//		for {
+//			select {
+//			case <-stream.Done():
+//				return
+//			case e := <-updates:
+//				if stream.SendJSON("update", e.ID, e) != nil {
+//					return
+//				}
+//			}
+//		}
+//	})
+func (c *Context) SSE() *EventStream {
+	s := &EventStream{
+		c:           c,
+		lastEventID: c.Request.Header.Get(HeaderLastEventID),
+		closed:      make(chan struct{}),
+	}
+	h := c.Response.Header()
+	h.Set(HeaderContentType, MIMETextEventStream)
+	h.Set(HeaderCacheControl, "no-cache")
+	// disable buffering of reverse proxies like nginx
+	h.Set("X-Accel-Buffering", "no")
+	c.Response.WriteHeader(http.StatusOK)
+	c.Response.Flush()
+
+	interval := defaultSSEKeepAlive
+	if c.router != nil && c.router.SSEKeepAlive != 0 {
+		interval = c.router.SSEKeepAlive
+	}
+	s.stopped.Add(1)
+	go s.run(interval)
+	c.onRelease(s.Close)
+	return s
+}
+
+// run sends keep-alive comments in the given interval (none if not positive)
+// until the stream is closed, closing it when the request is cancelled.
+func (s *EventStream) run(interval time.Duration) {
+	defer s.stopped.Done()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	done := s.c.Request.Context().Done()
+	for {
+		select {
+		case <-tick:
+			_ = s.Comment("keep-alive")
+		case <-done:
+			s.lock.Lock()
+			s.fail(s.c.Request.Context().Err())
+			s.lock.Unlock()
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// LastEventID returns the ID of the last event received by the client, as sent
+// in the Last-Event-ID header when reconnecting, "" if there is none.
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel that is closed when the stream is closed, i.e. when
+// Close was called, the request was cancelled (e.g. because the client went
+// away) or writing to the response failed.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Send sends an event with the given name, ID and data. The name and the ID are
+// omitted if empty, clients dispatch events without name as "message" events.
+// Data spanning multiple lines is sent as multiple data fields, the line breaks
+// are restored by the client.
+func (s *EventStream) Send(event, id, data string) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n\x00") {
+		return errInvalidEventField
+	}
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: ")
+		b.WriteString(event)
+		b.WriteByte('\n')
+	}
+	if id != "" {
+		b.WriteString("id: ")
+		b.WriteString(id)
+		b.WriteByte('\n')
+	}
+	data = strings.Replace(data, "\r\n", "\n", -1)
+	data = strings.Replace(data, "\r", "\n", -1)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return s.write(b.String())
+}
+
+// SendJSON sends an event with the given name and ID and the JSON encoding of v
+// as data.
+func (s *EventStream) SendJSON(event, id string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(event, id, string(b))
+}
+
+// Retry tells the client to wait for the given duration before reconnecting
+// when the connection is lost.
+func (s *EventStream) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(int64(d/time.Millisecond), 10) + "\n\n")
+}
+
+// Comment sends a comment, which is ignored by clients. Line breaks are
+// replaced by spaces.
+func (s *EventStream) Comment(text string) error {
+	text = strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+	return s.write(": " + text + "\n\n")
+}
+
+// Close closes the stream, stopping the keep-alive comments. The response is
+// finished when the handler returns. Close is called automatically after the
+// handler returned.
+func (s *EventStream) Close() {
+	s.lock.Lock()
+	s.fail(errStreamClosed)
+	s.lock.Unlock()
+	s.stopped.Wait()
+}
+
+// fail closes the stream because of the given error, unless it was closed
+// before. The lock must be held.
+func (s *EventStream) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// errStreamClosed is returned by the methods of closed event streams.
+var errStreamClosed = errors.New("event stream closed")
+
+// write writes the given message and flushes the response.
+func (s *EventStream) write(msg string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.c.Request.Context().Err(); err != nil {
+		s.fail(err)
+		return err
+	}
+	if _, err := io.WriteString(s.c.Response, msg); err != nil {
+		s.fail(err)
+		return err
+	}
+	s.c.Response.Flush()
+	return nil
+}
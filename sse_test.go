@@ -0,0 +1,120 @@
+package httprouter
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextSSE(t *testing.T) {
+	router := New()
+	router.SSEKeepAlive = -1
+	router.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		if err := stream.Retry(1500 * time.Millisecond); err != nil {
+			t.Error(err)
+		}
+		if err := stream.Send("", "", "hello"); err != nil {
+			t.Error(err)
+		}
+		if err := stream.Send("update", stream.LastEventID()+"1", "line 1\nline 2\r\nline 3"); err != nil {
+			t.Error(err)
+		}
+		if err := stream.SendJSON("json", "", map[string]int{"n": 1}); err != nil {
+			t.Error(err)
+		}
+		if err := stream.Comment("a\nb"); err != nil {
+			t.Error(err)
+		}
+		if err := stream.Send("bad\nname", "", ""); err != errInvalidEventField {
+			t.Errorf("wrong error for invalid event name: %v", err)
+		}
+		select {
+		case <-stream.Done():
+			t.Error("stream done before closing")
+		default:
+		}
+		go stream.Close()
+		select {
+		case <-stream.Done():
+		case <-time.After(time.Second):
+			t.Fatal("stream not done after closing")
+		}
+		if err := stream.Send("", "", "closed"); err == nil {
+			t.Error("no error sending to closed stream")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set(HeaderLastEventID, "4")
+	router.ServeHTTP(w, r)
+
+	if ct := w.Header().Get(HeaderContentType); ct != MIMETextEventStream {
+		t.Errorf("wrong content type: %s", ct)
+	}
+	if cc := w.Header().Get(HeaderCacheControl); cc != "no-cache" {
+		t.Errorf("wrong cache control: %s", cc)
+	}
+	want := "retry: 1500\n\n" +
+		"data: hello\n\n" +
+		"event: update\nid: 41\ndata: line 1\ndata: line 2\ndata: line 3\n\n" +
+		"event: json\ndata: {\"n\":1}\n\n" +
+		": a b\n\n"
+	if body := w.Body.String(); body != want {
+		t.Errorf("wrong body:\n%q\nwant:\n%q", body, want)
+	}
+}
+
+func TestContextSSEKeepAliveAndCancel(t *testing.T) {
+	done := make(chan struct{})
+	router := New()
+	router.SSEKeepAlive = 10 * time.Millisecond
+	router.GET("/events", func(c *Context) {
+		defer close(done)
+		stream := c.SSE()
+		if err := stream.Send("", "", "first"); err != nil {
+			t.Error(err)
+		}
+		<-stream.Done()
+		if err := stream.Send("", "", "late"); err == nil {
+			t.Error("no error sending after cancellation")
+		}
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	lines := bufio.NewReader(res.Body)
+	var got []string
+	for len(got) < 4 {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			got = append(got, line)
+		}
+	}
+	if got[0] != "data: first" || got[1] != ": keep-alive" || got[2] != ": keep-alive" {
+		t.Errorf("wrong stream: %q", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler not terminated after cancellation")
+	}
+}
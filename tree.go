@@ -79,6 +79,7 @@ type node struct {
 	priority  uint32
 	children  []*node
 	handle    Handle
+	route     *Route // the route the handle was registered with
 }
 
 // Increments priority of the given child and reorders if necessary
@@ -104,17 +105,19 @@ func (n *node) incrementChildPrio(pos int) int {
 	return newPos
 }
 
-// addRoute adds a node with the given handle to the path.
+// addRoute adds a node with the given handle to the path and returns the route
+// stored with the handle.
 // Not concurrency-safe!
-func (n *node) addRoute(path string, handle Handle) {
+func (n *node) addRoute(path string, handle Handle) *Route {
 	fullPath := path
+	route := &Route{Path: fullPath}
 	n.priority++
 
 	// Empty tree
 	if n.path == "" && n.indices == "" {
-		n.insertChild(path, fullPath, handle)
+		n.insertChild(path, fullPath, handle, route)
 		n.nType = root
-		return
+		return route
 	}
 
 walk:
@@ -133,6 +136,7 @@ walk:
 				indices:   n.indices,
 				children:  n.children,
 				handle:    n.handle,
+				route:     n.route,
 				priority:  n.priority - 1,
 			}
 
@@ -141,6 +145,7 @@ walk:
 			n.indices = string([]byte{n.path[i]})
 			n.path = path[:i]
 			n.handle = nil
+			n.route = nil
 			n.wildChild = false
 		}
 
@@ -201,8 +206,8 @@ walk:
 				n.incrementChildPrio(len(n.indices) - 1)
 				n = child
 			}
-			n.insertChild(path, fullPath, handle)
-			return
+			n.insertChild(path, fullPath, handle, route)
+			return route
 		}
 
 		// Otherwise add handle to current node
@@ -210,11 +215,12 @@ walk:
 			panic("a handle is already registered for path '" + fullPath + "'")
 		}
 		n.handle = handle
-		return
+		n.route = route
+		return route
 	}
 }
 
-func (n *node) insertChild(path, fullPath string, handle Handle) {
+func (n *node) insertChild(path, fullPath string, handle Handle, route *Route) {
 	for {
 		// Find prefix until first wildcard
 		wildcard, i, valid := findWildcard(path)
@@ -271,6 +277,7 @@ func (n *node) insertChild(path, fullPath string, handle Handle) {
 
 			// Otherwise we're done. Insert the handle in the new leaf
 			n.handle = handle
+			n.route = route
 			return
 		}
 
@@ -306,6 +313,7 @@ func (n *node) insertChild(path, fullPath string, handle Handle) {
 			path:     path[i:],
 			nType:    catchAll,
 			handle:   handle,
+			route:    route,
 			priority: 1,
 		}
 		n.children = []*node{child}
@@ -316,14 +324,15 @@ func (n *node) insertChild(path, fullPath string, handle Handle) {
 	// If no wildcard was found, simply insert the path and handle
 	n.path = path
 	n.handle = handle
+	n.route = route
 }
 
-// Returns the handle registered with the given path (key). The values of
-// wildcards are saved to a map.
+// Returns the handle registered with the given path (key) and the route it was
+// registered with. The values of wildcards are saved to a map.
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
-func (n *node) getValue(path string, params func() *Params) (handle Handle, ps *Params, tsr bool) {
+func (n *node) getValue(path string, params func() *Params) (handle Handle, ps *Params, route *Route, tsr bool) {
 walk: // Outer loop for walking the tree
 	for {
 		prefix := n.path
@@ -388,6 +397,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					if handle = n.handle; handle != nil {
+						route = n.route
 						return
 					} else if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
@@ -414,6 +424,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					handle = n.handle
+					route = n.route
 					return
 
 				default:
@@ -424,6 +435,7 @@ walk: // Outer loop for walking the tree
 			// We should have reached the node containing the handle.
 			// Check if this node has a handle registered.
 			if handle = n.handle; handle != nil {
+				route = n.route
 				return
 			}
 
@@ -0,0 +1,342 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxMemory is the default of Router.MaxMultipartMemory.
+const defaultMaxMemory = 32 << 20
+
+// defaultMaxMultipartSize is the default of Router.MaxMultipartSize.
+const defaultMaxMultipartSize = 128 << 20
+
+// sniffLen is the number of bytes used to detect the content type of uploaded
+// files, see http.DetectContentType.
+const sniffLen = 512
+
+// parseMultipartForm parses the multipart request body (see
+// Router.MaxMultipartMemory and Router.MaxMultipartSize) unless already parsed.
+// Temporary files are removed when the context object is released.
+func (c *Context) parseMultipartForm() error {
+	if c.Request.MultipartForm != nil {
+		return nil
+	}
+	maxMemory := int64(defaultMaxMemory)
+	maxSize := int64(defaultMaxMultipartSize)
+	if c.router != nil {
+		if c.router.MaxMultipartMemory > 0 {
+			maxMemory = c.router.MaxMultipartMemory
+		}
+		if c.router.MaxMultipartSize != 0 {
+			maxSize = c.router.MaxMultipartSize
+		}
+	}
+
+	var body *countingBody
+	if maxSize > 0 && c.Request.Body != nil {
+		if c.Request.ContentLength > maxSize {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, errUploadTooLarge)
+		}
+		body = &countingBody{ReadCloser: c.Request.Body}
+		c.Request.Body = http.MaxBytesReader(c.Response, body, maxSize)
+	}
+
+	if err := c.Request.ParseMultipartForm(maxMemory); err != nil {
+		// the error of the MaxBytesReader isn't passed on as is
+		if body != nil && body.n > maxSize {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, errUploadTooLarge)
+		}
+		if err == http.ErrNotMultipart {
+			return NewHTTPError(http.StatusUnsupportedMediaType, err)
+		}
+		return NewHTTPError(http.StatusBadRequest, err)
+	}
+	form := c.Request.MultipartForm
+	c.onRelease(func() { _ = form.RemoveAll() })
+	return nil
+}
+
+// countingBody counts the bytes read from a request body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// FormFile returns the first file uploaded under the given name with a
+// multipart request body. If there is none, an HTTPError with status code 400
+// wrapping http.ErrMissingFile is returned.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+	if fhs := c.Request.MultipartForm.File[name]; len(fhs) > 0 {
+		return fhs[0], nil
+	}
+	return nil, NewHTTPError(http.StatusBadRequest, fmt.Errorf("%w: %s", http.ErrMissingFile, name))
+}
+
+// MultipartReader returns a reader for the parts of the multipart request body,
+// to process the body as a stream. If the request body isn't multipart, an
+// HTTPError with status code 415 is returned.
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		if err == http.ErrNotMultipart {
+			return nil, NewHTTPError(http.StatusUnsupportedMediaType, err)
+		}
+		return nil, NewHTTPError(http.StatusBadRequest, err)
+	}
+	return mr, nil
+}
+
+// SaveUploadedFile saves the given uploaded file (see FormFile) to the file
+// with the given path.
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// UploadLimits restricts the files saved by SaveUploadedFiles.
+type UploadLimits struct {
+	// The maximum size of a single file in bytes, 0 for no limit.
+	MaxFileSize int64
+	// The maximum size of all parts (files and values) in bytes, 0 for no limit.
+	MaxTotalSize int64
+	// The maximum size of the values of all non-file parts in bytes, as they
+	// are kept in memory.
+	// Default: 10 MB
+	MaxValuesSize int64
+	// The allowed content types of files as detected by http.DetectContentType,
+	// e.g. "image/png" or "image/*". If empty, all content types are allowed.
+	AllowedTypes []string
+}
+
+// UploadedFile describes a file saved by SaveUploadedFiles.
+type UploadedFile struct {
+	// The name of the form field.
+	Field string
+	// The file name given by the client.
+	Filename string
+	// The content type detected by http.DetectContentType.
+	ContentType string
+	// The size of the file in bytes.
+	Size int64
+	// The path of the saved file.
+	Path string
+}
+
+// defaultMaxValuesSize is the default of UploadLimits.MaxValuesSize, the limit
+// http.Request.ParseMultipartForm applies to non-file parts.
+const defaultMaxValuesSize = 10 << 20
+
+// errUploadTooLarge is wrapped by the HTTPErrors of SaveUploadedFiles if a
+// size limit is exceeded.
+var errUploadTooLarge = errors.New("upload too large")
+
+// SaveUploadedFiles streams the parts of the multipart request body to disk,
+// checking the given limits: files are saved to the given directory under
+// their base name, made unique if a file of that name exists (see
+// UploadedFile.Path), or, if dir is empty, to temporary files which are removed
+// after the handler returned. The values of the other parts are returned as
+// url.Values.
+// If a limit is exceeded, an HTTPError with status code 413 (size limits) or
+// 415 (content types) is returned and all files saved so far are removed.
+func (c *Context) SaveUploadedFiles(dir string, limits UploadLimits) (files []UploadedFile, values url.Values, err error) {
+	mr, err := c.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			for _, f := range files {
+				_ = os.Remove(f.Path)
+			}
+			files, values = nil, nil
+		}
+	}()
+
+	values = make(url.Values)
+	var total, valuesSize int64
+	maxValuesSize := limits.MaxValuesSize
+	if maxValuesSize <= 0 {
+		maxValuesSize = defaultMaxValuesSize
+	}
+	// remaining returns the number of bytes allowed for the next part given the
+	// part limit (none if 0), -1 if unlimited
+	remaining := func(max int64) int64 {
+		left := int64(-1)
+		if max > 0 {
+			left = max
+		}
+		if limits.MaxTotalSize > 0 {
+			if r := limits.MaxTotalSize - total; left < 0 || r < left {
+				left = r
+			}
+		}
+		return left
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return files, values, nil
+		}
+		if err != nil {
+			return files, values, NewHTTPError(http.StatusBadRequest, err)
+		}
+
+		if part.FileName() == "" {
+			var b strings.Builder
+			max := maxValuesSize - valuesSize
+			if r := remaining(0); r >= 0 && r < max {
+				max = r
+			}
+			n, err := copyLimited(&b, part, max)
+			total += n
+			valuesSize += n
+			if err != nil {
+				return files, values, err
+			}
+			values.Add(part.FormName(), b.String())
+			continue
+		}
+
+		f, err := c.saveUploadedPart(part, dir, remaining(limits.MaxFileSize), limits)
+		if err != nil {
+			return files, values, err
+		}
+		total += f.Size
+		files = append(files, f)
+	}
+}
+
+// saveUploadedPart saves the given file part to the given directory (or a
+// temporary file), with at most max bytes (unlimited if max < 0).
+func (c *Context) saveUploadedPart(part *multipart.Part, dir string, max int64, limits UploadLimits) (uf UploadedFile, err error) {
+	uf = UploadedFile{Field: part.FormName(), Filename: part.FileName()}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return uf, NewHTTPError(http.StatusBadRequest, err)
+	}
+	head = head[:n]
+	uf.ContentType = http.DetectContentType(head)
+	if !allowedType(uf.ContentType, limits.AllowedTypes) {
+		return uf, NewHTTPError(http.StatusUnsupportedMediaType, fmt.Errorf("content type %q of file %q not allowed", uf.ContentType, uf.Filename))
+	}
+
+	var f *os.File
+	if dir == "" {
+		if f, err = ioutil.TempFile("", "upload-"); err != nil {
+			return uf, err
+		}
+		name := f.Name()
+		c.onRelease(func() { _ = os.Remove(name) })
+	} else {
+		base := filepath.Base(filepath.FromSlash(strings.Replace(uf.Filename, `\`, "/", -1)))
+		if base == "." || base == ".." || base == string(filepath.Separator) {
+			return uf, NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid file name %q", uf.Filename))
+		}
+		if f, err = createUnique(dir, base); err != nil {
+			return uf, err
+		}
+	}
+	uf.Path = f.Name()
+
+	uf.Size, err = copyLimited(f, io.MultiReader(bytes.NewReader(head), part), max)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(uf.Path)
+	}
+	return uf, err
+}
+
+// createUnique creates a new file with the given name in the given directory.
+// If a file of that name exists, a numeric suffix is added to the name (e.g.
+// "gopher-1.png"), existing files are never overwritten.
+func createUnique(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	for i := 0; ; i++ {
+		if i > 0 {
+			name = stem + "-" + strconv.Itoa(i) + ext
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < maxUniqueAttempts {
+			continue
+		}
+		return f, err
+	}
+}
+
+// maxUniqueAttempts is the number of suffixes tried by createUnique.
+const maxUniqueAttempts = 1000
+
+// copyLimited copies from src to dst, failing with an HTTPError with status
+// code 413 if src has more than max bytes (unlimited if max < 0).
+func copyLimited(dst io.Writer, src io.Reader, max int64) (int64, error) {
+	if max < 0 {
+		n, err := io.Copy(dst, src)
+		if err != nil {
+			return n, NewHTTPError(http.StatusBadRequest, err)
+		}
+		return n, nil
+	}
+	n, err := io.Copy(dst, io.LimitReader(src, max+1))
+	if err != nil {
+		return n, NewHTTPError(http.StatusBadRequest, err)
+	}
+	if n > max {
+		return n, NewHTTPError(http.StatusRequestEntityTooLarge, errUploadTooLarge)
+	}
+	return n, nil
+}
+
+// allowedType reports whether the content type is one of the allowed types,
+// which may have wildcard subtypes (e.g. "image/*"). All types are allowed if
+// none are given.
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	typ := strings.ToLower(mediaType(contentType))
+	for _, a := range allowed {
+		a = strings.ToLower(mediaType(a))
+		if a == typ || a == "*/*" || (strings.HasSuffix(a, "/*") && strings.HasPrefix(typ, a[:len(a)-1])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package httprouter
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// multipartRequest returns a request with a multipart body with the given
+// values and files (field name -> file name -> content).
+func multipartRequest(values map[string]string, files map[string]map[string][]byte) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range values {
+		_ = mw.WriteField(k, v)
+	}
+	for field, fs := range files {
+		for name, content := range fs {
+			fw, _ := mw.CreateFormFile(field, name)
+			_, _ = fw.Write(content)
+		}
+	}
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	return req
+}
+
+func TestContextFormFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := AcquireContextObject()
+	c.Request = multipartRequest(nil, map[string]map[string][]byte{"avatar": {"gopher.png": pngHeader}})
+	fh, err := c.FormFile("avatar")
+	if err != nil || fh.Filename != "gopher.png" {
+		t.Fatalf("wrong file: %v, %v", fh, err)
+	}
+	dst := filepath.Join(dir, "avatar.png")
+	if err = c.SaveUploadedFile(fh, dst); err != nil {
+		t.Fatalf("saving failed: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(dst); !bytes.Equal(b, pngHeader) {
+		t.Errorf("wrong content: %q", b)
+	}
+	var he *HTTPError
+	if _, err = c.FormFile("missing"); !errors.As(err, &he) || he.Code != http.StatusBadRequest || !errors.Is(err, http.ErrMissingFile) {
+		t.Errorf("expected HTTPError 400 wrapping http.ErrMissingFile, got %v", err)
+	}
+	ReleaseContextObject(c)
+
+	c = AcquireContextObject()
+	c.Request = httptest.NewRequest(http.MethodPost, "/upload", nil)
+	if _, err = c.FormFile("avatar"); !errors.As(err, &he) || he.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected HTTPError 415 for non-multipart body, got %v", err)
+	}
+	if _, err = c.MultipartReader(); !errors.As(err, &he) || he.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected HTTPError 415 for non-multipart body, got %v", err)
+	}
+	ReleaseContextObject(c)
+}
+
+func TestRouterMaxMultipartSize(t *testing.T) {
+	router := New()
+	router.MaxMultipartSize = 256
+	router.POST("/upload", HandleE(func(c *Context) error {
+		if _, err := c.FormFile("avatar"); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}).Handle())
+
+	small := map[string]map[string][]byte{"avatar": {"gopher.png": pngHeader}}
+	large := map[string]map[string][]byte{"avatar": {"gopher.png": bytes.Repeat(pngHeader, 64)}}
+	for _, test := range []struct {
+		name    string
+		files   map[string]map[string][]byte
+		chunked bool
+		code    int
+	}{
+		{"small", small, false, http.StatusNoContent},
+		{"large", large, false, http.StatusRequestEntityTooLarge},
+		{"large chunked", large, true, http.StatusRequestEntityTooLarge},
+	} {
+		r := multipartRequest(nil, test.files)
+		if test.chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: want status %d, got %d", test.name, test.code, w.Code)
+		}
+	}
+}
+
+func TestContextSaveUploadedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprouter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+	text := []byte("hello gopher")
+
+	// saved to the directory
+	c := AcquireContextObject()
+	c.Request = multipartRequest(map[string]string{"title": "gophers"}, map[string]map[string][]byte{
+		"image": {"../../gopher.png": png},
+	})
+	files, values, err := c.SaveUploadedFiles(dir, UploadLimits{MaxFileSize: 200, AllowedTypes: []string{"image/*"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values.Get("title") != "gophers" {
+		t.Errorf("wrong values: %v", values)
+	}
+	want := UploadedFile{Field: "image", Filename: "gopher.png", ContentType: "image/png", Size: int64(len(png)), Path: filepath.Join(dir, "gopher.png")}
+	if len(files) != 1 || files[0] != want {
+		t.Fatalf("wrong files:\n got %+v\nwant %+v", files, want)
+	}
+	ReleaseContextObject(c)
+	if b, _ := ioutil.ReadFile(want.Path); !bytes.Equal(b, png) {
+		t.Errorf("wrong content of saved file")
+	}
+
+	// existing files aren't overwritten
+	c = AcquireContextObject()
+	c.Request = multipartRequest(nil, map[string]map[string][]byte{"doc": {"gopher.png": text}})
+	files, _, err = c.SaveUploadedFiles(dir, UploadLimits{})
+	ReleaseContextObject(c)
+	if err != nil || len(files) != 1 || files[0].Filename != "gopher.png" || files[0].Path != filepath.Join(dir, "gopher-1.png") {
+		t.Fatalf("wrong files: %+v, %v", files, err)
+	}
+	if b, _ := ioutil.ReadFile(want.Path); !bytes.Equal(b, png) {
+		t.Errorf("existing file overwritten")
+	}
+	if b, _ := ioutil.ReadFile(files[0].Path); !bytes.Equal(b, text) {
+		t.Errorf("wrong content of saved file")
+	}
+
+	// saved to temporary files, removed after release
+	c = AcquireContextObject()
+	c.Request = multipartRequest(nil, map[string]map[string][]byte{"doc": {"a.txt": text}})
+	files, _, err = c.SaveUploadedFiles("", UploadLimits{})
+	if err != nil || len(files) != 1 || files[0].ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("wrong files: %+v, %v", files, err)
+	}
+	if _, err = os.Stat(files[0].Path); err != nil {
+		t.Errorf("temporary file missing: %v", err)
+	}
+	ReleaseContextObject(c)
+	if _, err = os.Stat(files[0].Path); !os.IsNotExist(err) {
+		t.Errorf("temporary file not removed: %v", err)
+	}
+
+	// limits
+	tests := []struct {
+		name   string
+		limits UploadLimits
+		status int
+	}{
+		{"file size", UploadLimits{MaxFileSize: 10}, http.StatusRequestEntityTooLarge},
+		{"total size", UploadLimits{MaxFileSize: 1000, MaxTotalSize: int64(len(png)) + 5}, http.StatusRequestEntityTooLarge},
+		{"content type", UploadLimits{AllowedTypes: []string{"image/png"}}, http.StatusUnsupportedMediaType},
+		{"values size", UploadLimits{MaxValuesSize: 5}, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		out, err := ioutil.TempDir(dir, "limits")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c = AcquireContextObject()
+		c.Request = multipartRequest(map[string]string{"title": "gophers"}, map[string]map[string][]byte{"image": {"a.png": png}, "text": {"b.txt": text}})
+		files, _, err = c.SaveUploadedFiles(out, test.limits)
+		var he *HTTPError
+		if !errors.As(err, &he) || he.Code != test.status {
+			t.Errorf("%s: expected HTTPError %d, got %v", test.name, test.status, err)
+		}
+		if files != nil {
+			t.Errorf("%s: files returned despite error", test.name)
+		}
+		if left, _ := ioutil.ReadDir(out); len(left) != 0 {
+			t.Errorf("%s: files not removed: %d", test.name, len(left))
+		}
+		ReleaseContextObject(c)
+	}
+}
@@ -0,0 +1,332 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationFunc is a validation rule. It reports whether the given value,
+// dereferenced if it is a pointer, is valid with regard to the rule parameter
+// (the part after '=' in the tag, e.g. "64" for "max=64", empty if none).
+type ValidationFunc func(v reflect.Value, param string) bool
+
+// Validator validates structs based on `validate:"..."` struct tags holding
+// comma separated rules, e.g. `validate:"required,min=1,max=64"`.
+//
+// Built-in rules are:
+//
+//	required   the value must not be the zero value (nil, "", 0, empty slice)
+//	omitempty  skip all other rules if the value is the zero value
+//	min=n      minimum length of strings (in runes), slices and maps or
+//	           minimum value of numbers
+//	max=n      maximum length or value, see min
+//	len=n      exact length or value, see min
+//	email      the string must be an e-mail address
+//	oneof=a b  the value must be one of the space separated values
+//
+// Struct fields and elements of slices of structs are validated recursively.
+type Validator struct {
+	lock  sync.RWMutex
+	rules map[string]ValidationFunc
+}
+
+// NewValidator returns a new Validator with the built-in rules.
+func NewValidator() *Validator {
+	return &Validator{
+		rules: map[string]ValidationFunc{
+			"required": validateRequired,
+			"min":      validateMin,
+			"max":      validateMax,
+			"len":      validateLen,
+			"email":    validateEmail,
+			"oneof":    validateOneOf,
+		},
+	}
+}
+
+// defaultValidator is used if Router.Validator is not set.
+var defaultValidator = NewValidator()
+
+// RegisterRule registers a custom rule under the given name, replacing any rule
+// (including built-in rules) registered with that name.
+func (val *Validator) RegisterRule(name string, fn ValidationFunc) {
+	if name == "" || name == "omitempty" || strings.ContainsAny(name, ",=") {
+		panic("invalid rule name '" + name + "'")
+	}
+	val.lock.Lock()
+	defer val.lock.Unlock()
+	val.rules[name] = fn
+}
+
+// RuleError describes a struct field that failed a validation rule.
+type RuleError struct {
+	// The name of the struct field, dotted for nested structs and with index for
+	// slice elements (e.g. "Items[0].Name").
+	Field string
+	// The name of the rule (e.g. "max").
+	Rule string
+	// The parameter of the rule (e.g. "64"), empty if none.
+	Param string
+}
+
+func (e RuleError) Error() string {
+	return e.Field + " " + e.message()
+}
+
+func (e RuleError) message() string {
+	switch e.Rule {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + e.Param
+	case "max":
+		return "must be at most " + e.Param
+	case "len":
+		return "must be exactly " + e.Param
+	case "email":
+		return "must be an e-mail address"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(e.Param), ", ")
+	}
+	if e.Param != "" {
+		return "failed " + e.Rule + "=" + e.Param
+	}
+	return "failed " + e.Rule
+}
+
+func (e RuleError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Field   string `json:"field"`
+		Rule    string `json:"rule"`
+		Param   string `json:"param,omitempty"`
+		Message string `json:"message"`
+	}{Field: e.Field, Rule: e.Rule, Param: e.Param, Message: e.message()})
+}
+
+// ValidationError is the error returned by Validator.Validate. It lists all
+// fields that failed validation.
+type ValidationError struct {
+	Fields []RuleError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate validates the given struct (or pointer to a struct) and returns a
+// *ValidationError listing all failing fields, or nil if the struct is valid.
+func (val *Validator) Validate(s interface{}) error {
+	v := reflect.ValueOf(s)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		panic("only structs can be validated")
+	}
+
+	val.lock.RLock()
+	defer val.lock.RUnlock()
+
+	var errs []RuleError
+	val.validateStruct(v, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// validateStruct validates the fields of the struct v, appending errors to errs.
+// Field names are prefixed with prefix.
+func (val *Validator) validateStruct(v reflect.Value, prefix string, errs *[]RuleError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { // unexported
+			continue
+		}
+		name := prefix + sf.Name
+		if sf.Anonymous {
+			name = strings.TrimSuffix(prefix, ".")
+		}
+		fv := v.Field(i)
+
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			if !val.validateField(fv, name, tag, errs) {
+				continue
+			}
+		}
+
+		// descend into structs and slices of structs
+		for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+			fv = fv.Elem()
+		}
+		switch fv.Kind() {
+		case reflect.Struct:
+			p := name + "."
+			if sf.Anonymous {
+				p = prefix
+			}
+			val.validateStruct(fv, p, errs)
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < fv.Len(); j++ {
+				ev := fv.Index(j)
+				for ev.Kind() == reflect.Ptr || ev.Kind() == reflect.Interface {
+					ev = ev.Elem()
+				}
+				if ev.Kind() == reflect.Struct {
+					val.validateStruct(ev, name+"["+strconv.Itoa(j)+"].", errs)
+				}
+			}
+		}
+	}
+}
+
+// validateField applies the rules of the tag to the field value. It reports
+// whether the value should be descended into.
+func (val *Validator) validateField(fv reflect.Value, name, tag string, errs *[]RuleError) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			rule, param = rule[:i], rule[i+1:]
+		}
+
+		if rule == "omitempty" {
+			if isZero(fv) {
+				return false
+			}
+			continue
+		}
+
+		fn, ok := val.rules[rule]
+		if !ok {
+			panic("unknown validation rule '" + rule + "' for field " + name)
+		}
+
+		// nil pointers only fail the required rule
+		v := fv
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && rule != "required" {
+			continue
+		}
+
+		if !fn(v, param) {
+			*errs = append(*errs, RuleError{Field: name, Rule: rule, Param: param})
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func validateRequired(v reflect.Value, _ string) bool {
+	return !isZero(v)
+}
+
+// size returns the length of strings, slices and maps and the value of numbers.
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// compareSize compares the size of the value (see size) with the parameter.
+func compareSize(v reflect.Value, param string, cmp func(s, p float64) bool) bool {
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid validation rule parameter %q", param))
+	}
+	s, ok := size(v)
+	return ok && cmp(s, p)
+}
+
+func validateMin(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(s, p float64) bool { return s >= p })
+}
+
+func validateMax(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(s, p float64) bool { return s <= p })
+}
+
+func validateLen(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(s, p float64) bool { return s == p })
+}
+
+func validateEmail(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	addr, err := mail.ParseAddress(v.String())
+	return err == nil && addr.Name == "" && addr.Address == v.String()
+}
+
+func validateOneOf(v reflect.Value, param string) bool {
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false
+	}
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
+
+// BindAndValidate binds the request to dst (see Bind) and validates the result
+// with the Router.Validator. If validation fails, an HTTPError with status code
+// 422 is returned, wrapping the *ValidationError and listing the failing fields
+// as details.
+func (c *Context) BindAndValidate(dst interface{}) error {
+	if err := c.Bind(dst); err != nil {
+		return err
+	}
+	val := defaultValidator
+	if c.router != nil && c.router.Validator != nil {
+		val = c.router.Validator
+	}
+	if err := val.Validate(dst); err != nil {
+		ve := err.(*ValidationError)
+		return &HTTPError{Code: http.StatusUnprocessableEntity, Err: ve, Details: ve.Fields}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package httprouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validateItem struct {
+	SKU   string `validate:"required,len=4"`
+	Count int    `validate:"min=1,max=10"`
+}
+
+type validateTarget struct {
+	Name    string         `json:"name" validate:"required,min=1,max=8"`
+	Email   string         `json:"email" validate:"required,email"`
+	Role    string         `json:"role" validate:"oneof=admin user"`
+	Nick    string         `json:"nick" validate:"omitempty,min=3"`
+	Age     *int           `json:"age" validate:"omitempty,min=18"`
+	Tags    []string       `json:"tags" validate:"max=2"`
+	Items   []validateItem `json:"items"`
+	Address struct {
+		Zip string `json:"zip" validate:"required,even"`
+	} `json:"address"`
+}
+
+func TestValidator(t *testing.T) {
+	val := NewValidator()
+	val.RegisterRule("even", func(v reflect.Value, _ string) bool {
+		return len(v.String())%2 == 0
+	})
+
+	valid := validateTarget{Name: "gopher", Email: "gopher@example.com", Role: "user"}
+	valid.Items = []validateItem{{SKU: "abcd", Count: 1}}
+	valid.Address.Zip = "1234"
+	if err := val.Validate(&valid); err != nil {
+		t.Fatalf("valid struct failed validation: %v", err)
+	}
+
+	age := 11
+	invalid := validateTarget{
+		Name:  "a gopher in disguise",
+		Email: "Gopher <gopher@example.com>",
+		Role:  "root",
+		Nick:  "go",
+		Age:   &age,
+		Tags:  []string{"a", "b", "c"},
+		Items: []validateItem{{SKU: "abcd", Count: 1}, {SKU: "abc", Count: 11}},
+	}
+	invalid.Address.Zip = "123"
+	err := val.Validate(invalid)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	want := []RuleError{
+		{Field: "Name", Rule: "max", Param: "8"},
+		{Field: "Email", Rule: "email"},
+		{Field: "Role", Rule: "oneof", Param: "admin user"},
+		{Field: "Nick", Rule: "min", Param: "3"},
+		{Field: "Age", Rule: "min", Param: "18"},
+		{Field: "Tags", Rule: "max", Param: "2"},
+		{Field: "Items[1].SKU", Rule: "len", Param: "4"},
+		{Field: "Items[1].Count", Rule: "max", Param: "10"},
+		{Field: "Address.Zip", Rule: "even"},
+	}
+	if !reflect.DeepEqual(ve.Fields, want) {
+		t.Errorf("wrong fields:\n got %+v\nwant %+v", ve.Fields, want)
+	}
+
+	var empty validateTarget
+	err = val.Validate(&empty)
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	want = []RuleError{
+		{Field: "Name", Rule: "required"},
+		{Field: "Email", Rule: "required"},
+		{Field: "Role", Rule: "oneof", Param: "admin user"},
+		{Field: "Address.Zip", Rule: "required"},
+	}
+	if !reflect.DeepEqual(ve.Fields, want) {
+		t.Errorf("wrong fields:\n got %+v\nwant %+v", ve.Fields, want)
+	}
+
+	recv := catchPanic(func() {
+		val.Validate(struct {
+			Name string `validate:"unknown"`
+		}{})
+	})
+	if recv == nil {
+		t.Error("no panic for unknown rule")
+	}
+}
+
+func TestContextBindAndValidate(t *testing.T) {
+	router := New()
+	router.Validator = NewValidator()
+	router.Validator.RegisterRule("even", func(v reflect.Value, _ string) bool {
+		return len(v.String())%2 == 0
+	})
+	router.POST("/users", HandleE(func(c *Context) error {
+		var dst validateTarget
+		if err := c.BindAndValidate(&dst); err != nil {
+			return err
+		}
+		c.NoContent(http.StatusCreated)
+		return nil
+	}).Handle())
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"name":"gopher","email":"gopher@example.com","role":"admin","address":{"zip":"12"}}`, http.StatusCreated},
+		{`{"name":"gopher","email":"gopher","role":"admin","address":{"zip":"12"}}`, http.StatusUnprocessableEntity},
+		{`{"name":42}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(test.body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		router.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s: wrong status code: want %d, got %d", test.body, test.status, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"gopher","role":"admin","address":{"zip":"1"}}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	router.ServeHTTP(w, req)
+	var body struct {
+		Details []map[string]string `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body %q: %v", w.Body.String(), err)
+	}
+	want := []map[string]string{
+		{"field": "Email", "rule": "required", "message": "is required"},
+		{"field": "Address.Zip", "rule": "even", "message": "failed even"},
+	}
+	if !reflect.DeepEqual(body.Details, want) {
+		t.Errorf("wrong details:\n got %v\nwant %v", body.Details, want)
+	}
+}
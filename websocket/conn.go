@@ -0,0 +1,485 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types, i.e. the opcodes of frames.
+const (
+	// TextMessage denotes a text message of UTF-8 encoded text.
+	TextMessage = 1
+	// BinaryMessage denotes a binary message.
+	BinaryMessage = 2
+	// CloseMessage denotes a close control message.
+	CloseMessage = 8
+	// PingMessage denotes a ping control message.
+	PingMessage = 9
+	// PongMessage denotes a pong control message.
+	PongMessage = 10
+
+	continuationFrame = 0
+)
+
+// Status codes of close messages, see RFC 6455, section 7.4.1.
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+// maxControlPayload is the maximum payload size of control frames.
+const maxControlPayload = 125
+
+// maxInt is the maximum value of int, no message can be longer.
+const maxInt = int64(^uint(0) >> 1)
+
+// readChunkSize is the payload length up to which payloads are allocated at
+// once. Longer payloads are read incrementally, such that the memory used
+// grows with the bytes received rather than with the length claimed by the
+// peer.
+const readChunkSize = 64 << 10
+
+var (
+	// ErrReadLimit is returned when a received message exceeds the read limit.
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrCloseSent is returned when writing after a close message was sent.
+	ErrCloseSent = errors.New("websocket: close sent")
+
+	errInvalidUTF8   = errors.New("websocket: invalid UTF-8 in text message")
+	errControlTooBig = errors.New("websocket: control message payload too large")
+	errMessageType   = errors.New("websocket: invalid message type")
+)
+
+// CloseError is returned by Conn.ReadMessage when the peer closed the
+// connection.
+type CloseError struct {
+	// The status code sent by the peer, CloseNoStatusReceived if there was
+	// none.
+	Code int
+	// The reason sent by the peer.
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket: close " + strconv.Itoa(e.Code)
+	if e.Text != "" {
+		s += ": " + e.Text
+	}
+	return s
+}
+
+// protocolError is the error of a received frame violating the protocol.
+type protocolError string
+
+func (e protocolError) Error() string {
+	return "websocket: protocol error: " + string(e)
+}
+
+// Conn is a WebSocket connection, see Upgrader.Upgrade.
+// A Conn supports one concurrent reader and any number of concurrent writers,
+// but messages written with NextWriter must not be interleaved with other data
+// messages.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+
+	// read state, only accessed by the reader
+	readLimit   int64
+	readErr     error
+	pongHandler func([]byte) error
+
+	writeLock sync.Mutex
+	bw        *bufio.Writer
+	closeSent bool
+}
+
+func newConn(conn net.Conn, brw *bufio.ReadWriter, subprotocol string, readLimit int64) *Conn {
+	return &Conn{
+		conn:        conn,
+		br:          brw.Reader,
+		bw:          brw.Writer,
+		subprotocol: subprotocol,
+		readLimit:   readLimit,
+	}
+}
+
+// Subprotocol returns the subprotocol selected in the handshake, "" if none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the network address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetReadDeadline sets the deadline for reading from the connection, a zero
+// value disables the deadline. After a read timed out, the connection is
+// broken and all reads return an error.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for writing to the connection, a zero
+// value disables the deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit sets the maximum size in bytes of received messages, see
+// Upgrader.ReadLimit.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetPongHandler sets the function called with the payload of received pong
+// messages, e.g. to extend the read deadline. If the function returns an
+// error, ReadMessage returns it.
+func (c *Conn) SetPongHandler(h func(data []byte) error) {
+	c.pongHandler = h
+}
+
+// ReadMessage reads the next data message, reassembling fragmented messages.
+// Control messages are handled while reading: pings are answered with pongs,
+// pongs are handed to the pong handler and a close message is answered and
+// returned as *CloseError. Frames violating the protocol, messages exceeding
+// the read limit and text messages of invalid UTF-8 close the connection with
+// the respective status code. Once an error was returned, all subsequent calls
+// return it.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, data, err = c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return messageType, data, err
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame(int64(len(message)), messageType != 0)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err = c.writeFrame(true, PongMessage, payload); err != nil && err != ErrCloseSent {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				if err = c.pongHandler(payload); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, protocolError("continuation frame without message"))
+			}
+			message = append(message, payload...)
+		default: // text or binary
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, protocolError("new message before end of fragmented message"))
+			}
+			messageType, message = opcode, payload
+		}
+
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(CloseInvalidFramePayloadData, errInvalidUTF8)
+			}
+			if message == nil {
+				message = []byte{}
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads the next frame, validating its header. The size is the size
+// of the message read so far, fragmented is whether a fragmented message is
+// being read.
+func (c *Conn) readFrame(size int64, fragmented bool) (fin bool, opcode int, payload []byte, err error) {
+	var h [8]byte
+	if _, err = io.ReadFull(c.br, h[:2]); err != nil {
+		return false, 0, nil, c.broken(err)
+	}
+	fin = h[0]&0x80 != 0
+	opcode = int(h[0] & 0x0f)
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, protocolError("reserved bits set"))
+	}
+	switch opcode {
+	case continuationFrame, TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		if !fin {
+			return false, 0, nil, c.fail(CloseProtocolError, protocolError("fragmented control frame"))
+		}
+	default:
+		return false, 0, nil, c.fail(CloseProtocolError, protocolError("unknown opcode "+strconv.Itoa(opcode)))
+	}
+	if h[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, protocolError("unmasked client frame"))
+	}
+
+	length := int64(h[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(c.br, h[:2]); err != nil {
+			return false, 0, nil, c.broken(err)
+		}
+		length = int64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err = io.ReadFull(c.br, h[:8]); err != nil {
+			return false, 0, nil, c.broken(err)
+		}
+		if h[0]&0x80 != 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, protocolError("invalid payload length"))
+		}
+		length = int64(binary.BigEndian.Uint64(h[:8]))
+	}
+	if opcode >= CloseMessage {
+		if length > maxControlPayload {
+			return false, 0, nil, c.fail(CloseProtocolError, errControlTooBig)
+		}
+	} else if length > maxInt-size || c.readLimit >= 0 && length > c.readLimit-size {
+		return false, 0, nil, c.fail(CloseMessageTooBig, ErrReadLimit)
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, c.broken(err)
+	}
+	if payload, err = readPayload(c.br, length); err != nil {
+		return false, 0, nil, c.broken(err)
+	}
+	for i := range payload {
+		payload[i] ^= mask[i&3]
+	}
+	return fin, opcode, payload, nil
+}
+
+// readPayload reads a payload of the given length.
+func readPayload(r io.Reader, length int64) ([]byte, error) {
+	if length <= readChunkSize {
+		payload := make([]byte, length)
+		_, err := io.ReadFull(r, payload)
+		return payload, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(readChunkSize)
+	_, err := io.CopyN(&buf, r, length)
+	return buf.Bytes(), err
+}
+
+// handleClose answers the given received close message and returns it as
+// *CloseError.
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, protocolError("invalid close payload"))
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.fail(CloseProtocolError, protocolError("invalid close code "+strconv.Itoa(closeErr.Code)))
+		}
+		if !utf8.ValidString(closeErr.Text) {
+			return c.fail(CloseInvalidFramePayloadData, errInvalidUTF8)
+		}
+	}
+	// echo the status code (if any) to complete the closing handshake
+	var echo []byte
+	if len(payload) >= 2 {
+		echo = payload[:2]
+	}
+	_ = c.writeFrame(true, CloseMessage, echo)
+	c.conn.Close()
+	return closeErr
+}
+
+// validCloseCode reports whether the given status code may be sent in a close
+// message.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011, code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// fail closes the connection with the given status code because of the given
+// error, which is returned.
+func (c *Conn) fail(code int, err error) error {
+	_ = c.writeFrame(true, CloseMessage, closePayload(code, err.Error()))
+	c.conn.Close()
+	return err
+}
+
+// broken returns the given error of reading from the network connection, an
+// unexpected EOF is reported as abnormal closure.
+func (c *Conn) broken(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &CloseError{Code: CloseAbnormalClosure, Text: io.ErrUnexpectedEOF.Error()}
+	}
+	return err
+}
+
+// WriteMessage writes a message of the given type (TextMessage, BinaryMessage,
+// PingMessage or PongMessage) in a single frame. The payload of control
+// messages is limited to 125 bytes.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return errControlTooBig
+		}
+	default:
+		return errMessageType
+	}
+	return c.writeFrame(true, messageType, data)
+}
+
+// NextWriter returns a writer for a fragmented message of the given type
+// (TextMessage or BinaryMessage): every write is sent as a frame of the
+// message, closing the writer ends the message. Control messages may be sent
+// while the message is written, other data messages must not.
+func (c *Conn) NextWriter(messageType int) (io.WriteCloser, error) {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return nil, errMessageType
+	}
+	return &messageWriter{c: c, opcode: messageType}, nil
+}
+
+// messageWriter writes a fragmented message, see Conn.NextWriter.
+type messageWriter struct {
+	c      *Conn
+	opcode int
+	closed bool
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("websocket: write to closed message writer")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.c.writeFrame(false, w.opcode, p); err != nil {
+		return 0, err
+	}
+	w.opcode = continuationFrame
+	return len(p), nil
+}
+
+func (w *messageWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.c.writeFrame(true, w.opcode, nil)
+}
+
+// WriteClose starts the closing handshake by sending a close message with the
+// given status code and reason (truncated to fit into a control frame).
+// Afterwards, ReadMessage should be called until it returns the peer's close
+// message before closing the connection with Close.
+func (c *Conn) WriteClose(code int, reason string) error {
+	return c.writeFrame(true, CloseMessage, closePayload(code, reason))
+}
+
+// Close closes the network connection. If no close message was sent before, a
+// close message with status CloseGoingAway is sent first.
+func (c *Conn) Close() error {
+	_ = c.writeFrame(true, CloseMessage, closePayload(CloseGoingAway, ""))
+	return c.conn.Close()
+}
+
+// closePayload returns the payload of a close message with the given status
+// code and reason, truncated to fit into a control frame.
+func closePayload(code int, reason string) []byte {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+		// don't cut UTF-8 sequences
+		for len(reason) > 0 && !utf8.ValidString(reason) {
+			reason = reason[:len(reason)-1]
+		}
+	}
+	p := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	copy(p[2:], reason)
+	return p
+}
+
+// writeFrame writes a single (unmasked) frame. Once a close message was sent,
+// ErrCloseSent is returned.
+func (c *Conn) writeFrame(fin bool, opcode int, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	var h [10]byte
+	h[0] = byte(opcode)
+	if fin {
+		h[0] |= 0x80
+	}
+	n := 2
+	switch length := len(payload); {
+	case length <= 125:
+		h[1] = byte(length)
+	case length <= 0xffff:
+		h[1] = 126
+		binary.BigEndian.PutUint16(h[2:], uint16(length))
+		n += 2
+	default:
+		h[1] = 127
+		binary.BigEndian.PutUint64(h[2:], uint64(length))
+		n += 8
+	}
+	if _, err := c.bw.Write(h[:n]); err != nil {
+		return err
+	}
+	if _, err := c.bw.Write(payload); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
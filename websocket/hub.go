@@ -0,0 +1,44 @@
+package websocket
+
+import (
+	"github.com/heimdalr/httprouter"
+)
+
+// ServeHub returns a handle upgrading requests to WebSocket connections and
+// sending the messages published to the topic of the given route parameters
+// (see httprouter.Hub.Subscribe) as text messages, until the client closes the
+// connection or is disconnected as slow consumer (with status
+// ClosePolicyViolation). Messages received from the client are discarded.
+func (u *Upgrader) ServeHub(hub *httprouter.Hub, params ...string) httprouter.Handle {
+	return httprouter.HandleE(func(c *httprouter.Context) error {
+		conn, err := u.Upgrade(c)
+		if err != nil {
+			return err
+		}
+		s := hub.Subscribe(c, params...)
+
+		// read until the client closes the connection to answer pings
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					s.Close()
+					return
+				}
+			}
+		}()
+
+		for m := range s.C() {
+			if err = conn.WriteMessage(TextMessage, m.Data); err != nil {
+				break
+			}
+		}
+		if s.Err() == httprouter.ErrSlowConsumer {
+			_ = conn.WriteClose(ClosePolicyViolation, "slow consumer")
+		}
+		conn.Close()
+		<-readDone
+		return nil
+	}).Handle()
+}
@@ -0,0 +1,203 @@
+// Package websocket implements the WebSocket protocol (RFC 6455) for handlers
+// of github.com/heimdalr/httprouter.
+//
+// A handler upgrades the connection with an Upgrader and then exchanges
+// messages on the returned Conn until either side closes the connection:
+//
+//	var upgrader websocket.Upgrader
+//
+//	router.GET("/echo", httprouter.HandleE(func(c *httprouter.Context) error {
+//		conn, err := upgrader.Upgrade(c)
+//		if err != nil {
+//			return err // the handshake failed, nothing was written
+//		}
+//		defer conn.Close()
+//		for {
+//			typ, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return nil // the connection is closed
+//			}
+//			if err = conn.WriteMessage(typ, msg); err != nil {
+//				return nil
+//			}
+//		}
+//	}).Handle())
+//
+// Extensions (e.g. permessage-deflate) are not supported.
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/heimdalr/httprouter"
+)
+
+// Header names of the handshake.
+const (
+	HeaderSecWebSocketKey      = "Sec-WebSocket-Key"
+	HeaderSecWebSocketAccept   = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketVersion  = "Sec-WebSocket-Version"
+	HeaderSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+)
+
+// acceptGUID is the GUID the Sec-WebSocket-Accept header is derived with.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultReadLimit is the default maximum size of received messages, see
+// Upgrader.ReadLimit.
+const DefaultReadLimit = 1 << 20
+
+// Upgrader upgrades HTTP requests to WebSocket connections. The zero value is
+// ready to use.
+type Upgrader struct {
+	// The maximum size in bytes of received messages, larger messages close
+	// the connection with status CloseMessageTooBig. Negative values disable
+	// the limit, memory is then bounded only by the data the peer actually
+	// sends.
+	// Default: DefaultReadLimit
+	ReadLimit int64
+
+	// The subprotocols supported by the server in order of preference. The
+	// first of them requested by the client is selected (see
+	// Conn.Subprotocol).
+	Subprotocols []string
+
+	// CheckOrigin reports whether the Origin header of the request is
+	// acceptable. If not set, requests without Origin header and requests with
+	// an origin of the same host as the request are accepted.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade performs the WebSocket handshake and returns the connection taken
+// over from the HTTP server. Headers set on the response before (e.g. cookies)
+// are sent with the handshake response.
+// If the request isn't a valid WebSocket handshake, an *httprouter.HTTPError
+// with the appropriate status code is returned and nothing is written, such
+// that the error can be rendered as usual (e.g. by returning it from a
+// HandleE). After a successful upgrade the response must not be used anymore.
+func (u *Upgrader) Upgrade(c *httprouter.Context) (*Conn, error) {
+	r := c.Request
+	if r.Method != http.MethodGet {
+		c.Response.Header().Set(httprouter.HeaderAllow, http.MethodGet)
+		return nil, handshakeError(http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, httprouter.HeaderUpgrade, "websocket") {
+		return nil, handshakeError(http.StatusBadRequest, "request is not a websocket upgrade")
+	}
+	if r.Header.Get(HeaderSecWebSocketVersion) != "13" {
+		c.Response.Header().Set(HeaderSecWebSocketVersion, "13")
+		return nil, handshakeError(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get(HeaderSecWebSocketKey)
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, handshakeError(http.StatusBadRequest, "invalid "+HeaderSecWebSocketKey+" header")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, handshakeError(http.StatusForbidden, "origin not allowed")
+	}
+	subprotocol := u.selectSubprotocol(r)
+
+	hijacker, ok := c.Response.(http.Hijacker)
+	if !ok {
+		return nil, handshakeError(http.StatusInternalServerError, "response doesn't support hijacking")
+	}
+	header := c.Response.Header()
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, httprouter.NewHTTPError(http.StatusInternalServerError, err)
+	}
+	// clear the deadlines set by the HTTP server
+	_ = netConn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString(HeaderSecWebSocketAccept + ": " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString(HeaderSecWebSocketProtocol + ": " + subprotocol + "\r\n")
+	}
+	for name, values := range header {
+		switch name {
+		case httprouter.HeaderUpgrade, "Connection", HeaderSecWebSocketAccept, HeaderSecWebSocketProtocol:
+			continue
+		}
+		for _, v := range values {
+			b.WriteString(name + ": " + strings.NewReplacer("\r", " ", "\n", " ").Replace(v) + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	if _, err = brw.WriteString(b.String()); err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	readLimit := u.ReadLimit
+	if readLimit == 0 {
+		readLimit = DefaultReadLimit
+	}
+	return newConn(netConn, brw, subprotocol, readLimit), nil
+}
+
+// handshakeError returns the error of a failed handshake.
+func handshakeError(code int, msg string) error {
+	return httprouter.NewHTTPError(code, errors.New("websocket: "+msg))
+}
+
+// selectSubprotocol returns the first supported subprotocol requested by the
+// client, "" if there is none.
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, supported := range u.Subprotocols {
+		if headerContainsToken(r.Header, HeaderSecWebSocketProtocol, supported) {
+			return supported
+		}
+	}
+	return ""
+}
+
+// acceptKey returns the Sec-WebSocket-Accept header for the given
+// Sec-WebSocket-Key header.
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// sameOrigin reports whether the request has no Origin header or an origin of
+// the same host as the request.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(httprouter.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContainsToken reports whether the comma separated list of the header
+// with the given name contains the given token (case insensitive).
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,462 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heimdalr/httprouter"
+	"github.com/rs/zerolog"
+)
+
+// testClient is a minimal WebSocket client writing raw frames.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+	res  *http.Response
+}
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// dial connects to the given test server and performs the handshake with the
+// given extra headers.
+func dial(t *testing.T, server *httptest.Server, path string, header http.Header) *testClient {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set(HeaderSecWebSocketVersion, "13")
+	req.Header.Set(HeaderSecWebSocketKey, testKey)
+	for name, values := range header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if err = req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testClient{t: t, conn: conn, br: br, res: res}
+}
+
+// writeFrame writes a frame, masked unless the mask is nil.
+func (tc *testClient) writeFrame(fin bool, opcode byte, payload []byte, mask []byte) {
+	var b bytes.Buffer
+	h0 := opcode
+	if fin {
+		h0 |= 0x80
+	}
+	b.WriteByte(h0)
+	var maskBit byte
+	if mask != nil {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		b.WriteByte(maskBit | byte(len(payload)))
+	case len(payload) <= 0xffff:
+		b.WriteByte(maskBit | 126)
+		_ = binary.Write(&b, binary.BigEndian, uint16(len(payload)))
+	default:
+		b.WriteByte(maskBit | 127)
+		_ = binary.Write(&b, binary.BigEndian, uint64(len(payload)))
+	}
+	if mask != nil {
+		b.Write(mask)
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+	b.Write(payload)
+	if _, err := tc.conn.Write(b.Bytes()); err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+var testMask = []byte{1, 2, 3, 4}
+
+// writeHeader writes the header of a masked frame with the given payload
+// length, but no payload.
+func (tc *testClient) writeHeader(fin bool, opcode byte, length uint64) {
+	h := []byte{opcode, 0x80 | 127, 0, 0, 0, 0, 0, 0, 0, 0}
+	if fin {
+		h[0] |= 0x80
+	}
+	binary.BigEndian.PutUint64(h[2:], length)
+	if _, err := tc.conn.Write(append(h, testMask...)); err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+func (tc *testClient) send(opcode byte, payload string) {
+	tc.writeFrame(true, opcode, []byte(payload), testMask)
+}
+
+// readFrame reads an unmasked frame.
+func (tc *testClient) readFrame() (fin bool, opcode byte, payload []byte) {
+	var h [2]byte
+	if _, err := io.ReadFull(tc.br, h[:]); err != nil {
+		tc.t.Fatal(err)
+	}
+	if h[1]&0x80 != 0 {
+		tc.t.Fatal("server frame masked")
+	}
+	length := uint64(h[1] & 0x7f)
+	switch length {
+	case 126:
+		var l uint16
+		_ = binary.Read(tc.br, binary.BigEndian, &l)
+		length = uint64(l)
+	case 127:
+		_ = binary.Read(tc.br, binary.BigEndian, &length)
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(tc.br, payload); err != nil {
+		tc.t.Fatal(err)
+	}
+	return h[0]&0x80 != 0, h[0] & 0x0f, payload
+}
+
+// expectClose reads a close frame and checks its status code.
+func (tc *testClient) expectClose(code int) {
+	_, opcode, payload := tc.readFrame()
+	if opcode != CloseMessage || len(payload) < 2 {
+		tc.t.Fatalf("expected close frame, got opcode %d: %q", opcode, payload)
+	}
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		tc.t.Errorf("wrong close code: want %d, got %d (%s)", code, got, payload[2:])
+	}
+}
+
+// echoServer starts a server echoing messages, the errors ending the
+// connections are sent to the returned channel.
+func echoServer(u *Upgrader) (*httptest.Server, chan error) {
+	errs := make(chan error, 1)
+	logger := zerolog.Nop()
+	router := httprouter.New()
+	router.Logger = &logger
+	router.GET("/ws", httprouter.HandleE(func(c *httprouter.Context) error {
+		c.Response.Header().Set("X-Test", "1")
+		conn, err := u.Upgrade(c)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				errs <- err
+				return nil
+			}
+			if string(msg) == "fragment" {
+				w, _ := conn.NextWriter(typ)
+				_, _ = w.Write([]byte("frag"))
+				_, _ = w.Write([]byte("ment"))
+				err = w.Close()
+			} else {
+				err = conn.WriteMessage(typ, msg)
+			}
+			if err != nil {
+				errs <- err
+				return nil
+			}
+		}
+	}).Handle())
+	return httptest.NewServer(router), errs
+}
+
+func TestUpgradeHandshake(t *testing.T) {
+	server, _ := echoServer(&Upgrader{Subprotocols: []string{"v2", "v1"}})
+	defer server.Close()
+
+	tc := dial(t, server, "/ws", http.Header{HeaderSecWebSocketProtocol: {"v1, v2"}})
+	defer tc.conn.Close()
+	res := tc.res
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("wrong status: %d", res.StatusCode)
+	}
+	if accept := res.Header.Get(HeaderSecWebSocketAccept); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("wrong accept key: %s", accept)
+	}
+	if p := res.Header.Get(HeaderSecWebSocketProtocol); p != "v2" {
+		t.Errorf("wrong subprotocol: %s", p)
+	}
+	if res.Header.Get("X-Test") != "1" {
+		t.Error("response header not sent")
+	}
+}
+
+func TestUpgradeRejected(t *testing.T) {
+	u := &Upgrader{}
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		code   int
+	}{
+		{"method", http.MethodPost, nil, http.StatusMethodNotAllowed},
+		{"no upgrade", http.MethodGet, http.Header{"Upgrade": nil}, http.StatusBadRequest},
+		{"version", http.MethodGet, http.Header{HeaderSecWebSocketVersion: {"8"}}, http.StatusUpgradeRequired},
+		{"key", http.MethodGet, http.Header{HeaderSecWebSocketKey: {"c2hvcnQ="}}, http.StatusBadRequest},
+		{"origin", http.MethodGet, http.Header{"Origin": {"http://evil.example"}}, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "http://example.com/ws", nil)
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set(HeaderSecWebSocketVersion, "13")
+		r.Header.Set(HeaderSecWebSocketKey, testKey)
+		for name, values := range test.header {
+			r.Header.Del(name)
+			for _, v := range values {
+				r.Header.Add(name, v)
+			}
+		}
+		w := httptest.NewRecorder()
+		c := httprouter.AcquireContextObject()
+		c.Request, c.Response = r, httprouter.NewResponseWriter(w)
+
+		conn, err := u.Upgrade(c)
+		var he *httprouter.HTTPError
+		if conn != nil || !errors.As(err, &he) || he.Code != test.code {
+			t.Errorf("%s: want status %d, got %v", test.name, test.code, err)
+		}
+		if c.Response.Written() {
+			t.Errorf("%s: response written", test.name)
+		}
+		if test.code == http.StatusUpgradeRequired && c.Response.Header().Get(HeaderSecWebSocketVersion) != "13" {
+			t.Errorf("%s: supported version not announced", test.name)
+		}
+		httprouter.ReleaseContextObject(c)
+	}
+
+	// the same origin is accepted by default
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+	r.Header.Set("Origin", "https://EXAMPLE.com")
+	if !sameOrigin(r) {
+		t.Error("same origin rejected")
+	}
+}
+
+func TestConnEcho(t *testing.T) {
+	server, errs := echoServer(&Upgrader{})
+	defer server.Close()
+	tc := dial(t, server, "/ws", nil)
+	defer tc.conn.Close()
+
+	tc.send(TextMessage, "hello")
+	if fin, opcode, payload := tc.readFrame(); !fin || opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("wrong echo: %v %d %q", fin, opcode, payload)
+	}
+	big := bytes.Repeat([]byte{0xff}, 70000)
+	tc.writeFrame(true, BinaryMessage, big, testMask)
+	if _, opcode, payload := tc.readFrame(); opcode != BinaryMessage || !bytes.Equal(payload, big) {
+		t.Errorf("wrong binary echo: %d, %d bytes", opcode, len(payload))
+	}
+
+	// fragmented message with an interleaved ping
+	tc.writeFrame(false, TextMessage, []byte("hel"), testMask)
+	tc.writeFrame(true, PingMessage, []byte("ping"), testMask)
+	tc.writeFrame(false, continuationFrame, []byte("lo "), testMask)
+	tc.writeFrame(true, continuationFrame, []byte("again"), testMask)
+	if _, opcode, payload := tc.readFrame(); opcode != PongMessage || string(payload) != "ping" {
+		t.Errorf("wrong pong: %d %q", opcode, payload)
+	}
+	if _, opcode, payload := tc.readFrame(); opcode != TextMessage || string(payload) != "hello again" {
+		t.Errorf("wrong reassembled message: %d %q", opcode, payload)
+	}
+
+	// fragmented message written with NextWriter
+	tc.send(TextMessage, "fragment")
+	for _, want := range []struct {
+		fin     bool
+		opcode  byte
+		payload string
+	}{{false, TextMessage, "frag"}, {false, continuationFrame, "ment"}, {true, continuationFrame, ""}} {
+		if fin, opcode, payload := tc.readFrame(); fin != want.fin || opcode != want.opcode || string(payload) != want.payload {
+			t.Errorf("wrong fragment: %v %d %q", fin, opcode, payload)
+		}
+	}
+
+	// closing handshake
+	tc.send(CloseMessage, "\x03\xe8bye")
+	tc.expectClose(CloseNormalClosure)
+	err := <-errs
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseNormalClosure || ce.Text != "bye" {
+		t.Errorf("wrong close error: %v", err)
+	}
+}
+
+func TestConnProtocolErrors(t *testing.T) {
+	server, errs := echoServer(&Upgrader{ReadLimit: 10})
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		write func(tc *testClient)
+		code  int
+	}{
+		{"unmasked", func(tc *testClient) {
+			tc.writeFrame(true, TextMessage, []byte("hi"), nil)
+		}, CloseProtocolError},
+		{"read limit", func(tc *testClient) {
+			tc.send(BinaryMessage, "more than ten bytes")
+		}, CloseMessageTooBig},
+		{"fragmented read limit", func(tc *testClient) {
+			tc.writeFrame(false, BinaryMessage, []byte("123456"), testMask)
+			tc.writeFrame(true, continuationFrame, []byte("789012"), testMask)
+		}, CloseMessageTooBig},
+		{"overflowing read limit", func(tc *testClient) {
+			tc.writeFrame(false, TextMessage, []byte("a"), testMask)
+			tc.writeHeader(true, continuationFrame, 0x7fffffffffffffff)
+		}, CloseMessageTooBig},
+		{"invalid UTF-8", func(tc *testClient) {
+			tc.send(TextMessage, "\xff\xfe")
+		}, CloseInvalidFramePayloadData},
+		{"reserved bits", func(tc *testClient) {
+			tc.writeFrame(true, 0x40|TextMessage, []byte("hi"), testMask)
+		}, CloseProtocolError},
+		{"unknown opcode", func(tc *testClient) {
+			tc.send(3, "hi")
+		}, CloseProtocolError},
+		{"fragmented control", func(tc *testClient) {
+			tc.writeFrame(false, PingMessage, nil, testMask)
+		}, CloseProtocolError},
+		{"large control", func(tc *testClient) {
+			tc.send(PingMessage, strings.Repeat("x", 126))
+		}, CloseProtocolError},
+		{"continuation", func(tc *testClient) {
+			tc.send(continuationFrame, "hi")
+		}, CloseProtocolError},
+		{"interrupted fragments", func(tc *testClient) {
+			tc.writeFrame(false, TextMessage, []byte("a"), testMask)
+			tc.send(TextMessage, "b")
+		}, CloseProtocolError},
+		{"close code", func(tc *testClient) {
+			tc.send(CloseMessage, "\x03\xed")
+		}, CloseProtocolError},
+	}
+	for _, test := range tests {
+		tc := dial(t, server, "/ws", nil)
+		test.write(tc)
+		tc.expectClose(test.code)
+		if err := <-errs; err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+		tc.conn.Close()
+	}
+
+	// without read limit the length still has to fit into memory
+	unlimited, errs := echoServer(&Upgrader{ReadLimit: -1})
+	defer unlimited.Close()
+	tc := dial(t, unlimited, "/ws", nil)
+	defer tc.conn.Close()
+	tc.writeFrame(false, TextMessage, []byte("a"), testMask)
+	tc.writeHeader(true, continuationFrame, 0x7fffffffffffffff)
+	tc.expectClose(CloseMessageTooBig)
+	if err := <-errs; err != ErrReadLimit {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestConnServerClose(t *testing.T) {
+	done := make(chan error, 1)
+	router := httprouter.New()
+	logger := zerolog.Nop()
+	router.Logger = &logger
+	router.GET("/ws", httprouter.HandleE(func(c *httprouter.Context) error {
+		conn, err := (&Upgrader{}).Upgrade(c)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if err = conn.WriteClose(CloseGoingAway, "shutdown"); err != nil {
+			return err
+		}
+		if conn.WriteMessage(TextMessage, []byte("late")) != ErrCloseSent {
+			t.Error("write after close succeeded")
+		}
+		_, _, err = conn.ReadMessage()
+		done <- err
+		return nil
+	}).Handle())
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	tc := dial(t, server, "/ws", nil)
+	defer tc.conn.Close()
+	_, opcode, payload := tc.readFrame()
+	if opcode != CloseMessage || string(payload[2:]) != "shutdown" {
+		t.Fatalf("wrong close frame: %d %q", opcode, payload)
+	}
+	tc.send(CloseMessage, string(payload[:2]))
+	var ce *CloseError
+	if err := <-done; !errors.As(err, &ce) || ce.Code != CloseGoingAway {
+		t.Errorf("wrong close error: %v", err)
+	}
+}
+
+func TestUpgraderServeHub(t *testing.T) {
+	hub := httprouter.NewHub()
+	hub.BufferSize = 1
+	hub.Policy = httprouter.Disconnect
+	logger := zerolog.Nop()
+	router := httprouter.New()
+	router.Logger = &logger
+	router.GET("/rooms/:room", (&Upgrader{}).ServeHub(hub, "room"))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	waitSubscribers := func(n int) {
+		for i := 0; hub.Subscribers("lobby") != n; i++ {
+			if i == 100 {
+				t.Fatalf("want %d subscribers, got %d", n, hub.Subscribers("lobby"))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	tc := dial(t, server, "/rooms/lobby", nil)
+	defer tc.conn.Close()
+	waitSubscribers(1)
+	hub.Publish("lobby", httprouter.Message{Data: []byte("hi")})
+	if _, opcode, payload := tc.readFrame(); opcode != TextMessage || string(payload) != "hi" {
+		t.Errorf("wrong message: %d %q", opcode, payload)
+	}
+
+	// closing the connection unsubscribes
+	tc.send(CloseMessage, "\x03\xe8")
+	tc.expectClose(CloseNormalClosure)
+	waitSubscribers(0)
+
+	// slow consumers are disconnected: the handler is blocked writing to a
+	// client which doesn't read
+	slow := dial(t, server, "/rooms/lobby", nil)
+	defer slow.conn.Close()
+	waitSubscribers(1)
+	big := httprouter.Message{Data: bytes.Repeat([]byte("x"), 1<<20)}
+	for i := 0; i < 100 && hub.Subscribers("lobby") == 1; i++ {
+		hub.Publish("lobby", big)
+	}
+	waitSubscribers(0)
+}